@@ -15,6 +15,112 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/clip": {
+            "get": {
+                "description": "Recording of camera for time range, e.g. from=18:30 or from=10 minutes ago",
+                "produces": [
+                    "video/mp4"
+                ],
+                "tags": [
+                    "recordings"
+                ],
+                "summary": "Get recording clip",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Camera, instance/camera for named instances",
+                        "name": "camera",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time: 18:30, 2024-05-01 18:30, 10 minutes ago or unix timestamp",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Duration, e.g. 90s, 5m or 5 minutes",
+                        "name": "duration",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "502": {
+                        "description": "Bad Gateway"
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Events processed by bot, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Get events history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Frigate instance",
+                        "name": "instance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Camera",
+                        "name": "camera",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sent, skipped, collapsed, digest or backlog",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "24h",
+                        "description": "Duration, e.g. 2h or 7d",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Max count of events",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "502": {
+                        "description": "Bad Gateway"
+                    }
+                }
+            }
+        },
         "/mute": {
             "get": {
                 "description": "Mute send event",
@@ -58,6 +164,38 @@ const docTemplate = `{
                 }
             }
         },
+        "/report": {
+            "get": {
+                "description": "Summary of events for period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "report"
+                ],
+                "summary": "Get report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "today",
+                        "description": "today, yesterday, day or week",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "502": {
+                        "description": "Bad Gateway"
+                    }
+                }
+            }
+        },
         "/resume": {
             "get": {
                 "description": "Resume send event",
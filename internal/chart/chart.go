@@ -0,0 +1,191 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	Background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	Foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	Grid       = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	Bar        = color.RGBA{0x2e, 0x86, 0xde, 0xff}
+)
+
+const (
+	margin     = 40
+	lineHeight = 13
+)
+
+// Go Regular has Latin, Greek and Cyrillic glyphs, so localized labels and
+// camera names are readable
+var face = newFace()
+
+func newFace() font.Face {
+	f, err := opentype.Parse(goregular.TTF)
+	if err == nil {
+		var face font.Face
+		face, err = opentype.NewFace(f, &opentype.FaceOptions{Size: 12, DPI: 72, Hinting: font.HintingFull})
+		if err == nil {
+			return face
+		}
+	}
+	log.Warn.Println("Error loading chart font, using basic font: " + err.Error())
+	return basicfont.Face7x13
+}
+
+// EncodePNG encodes chart to PNG bytes
+func EncodePNG(Img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, Img)
+	return buf.Bytes(), err
+}
+
+func newCanvas(Width int, Height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{Background}, image.Point{}, draw.Src)
+	return img
+}
+
+func fillRect(Img *image.RGBA, Rect image.Rectangle, Color color.Color) {
+	draw.Draw(Img, Rect, &image.Uniform{Color}, image.Point{}, draw.Src)
+}
+
+// drawText draws text with baseline at X, Y
+func drawText(Img *image.RGBA, X int, Y int, Text string) {
+	d := font.Drawer{
+		Dst:  Img,
+		Src:  &image.Uniform{Foreground},
+		Face: face,
+		Dot:  fixed.P(X, Y),
+	}
+	d.DrawString(Text)
+}
+
+// drawTextRight draws text ending at X
+func drawTextRight(Img *image.RGBA, X int, Y int, Text string) {
+	drawText(Img, X-textWidth(Text), Y, Text)
+}
+
+func textWidth(Text string) int {
+	return font.MeasureString(face, Text).Ceil()
+}
+
+func maxValue(Values []int) int {
+	max := 0
+	for _, v := range Values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// HourHistogram renders events per hour of day
+func HourHistogram(Hours [24]int) image.Image {
+	width, height := 800, 400
+	img := newCanvas(width, height)
+
+	plot := image.Rect(margin, margin, width-margin/2, height-margin)
+	max := maxValue(Hours[:])
+	if max == 0 {
+		max = 1
+	}
+	for _, level := range []int{0, max / 2, max} {
+		y := plot.Max.Y - level*plot.Dy()/max
+		fillRect(img, image.Rect(plot.Min.X, y, plot.Max.X, y+1), Grid)
+		drawTextRight(img, plot.Min.X-4, y+lineHeight/3, strconv.Itoa(level))
+	}
+
+	step := plot.Dx() / 24
+	for hour, count := range Hours {
+		x := plot.Min.X + hour*step
+		h := count * plot.Dy() / max
+		fillRect(img, image.Rect(x+2, plot.Max.Y-h, x+step-2, plot.Max.Y), Bar)
+		if hour%3 == 0 {
+			drawText(img, x+2, plot.Max.Y+lineHeight+2, strconv.Itoa(hour))
+		}
+	}
+	return img
+}
+
+// BarChart renders horizontal bars with labels, e.g. events per camera
+func BarChart(Labels []string, Values []int) image.Image {
+	labelWidth := 0
+	for _, label := range Labels {
+		if textWidth(label) > labelWidth {
+			labelWidth = textWidth(label)
+		}
+	}
+	barHeight := 24
+	width := 800
+	height := margin*2 + len(Labels)*barHeight
+	img := newCanvas(width, height)
+
+	left := margin/2 + labelWidth + 8
+	plotWidth := width - left - margin*2
+	max := maxValue(Values)
+	if max == 0 {
+		max = 1
+	}
+	for i, label := range Labels {
+		y := margin + i*barHeight
+		drawTextRight(img, left-8, y+barHeight/2+lineHeight/3, label)
+		w := Values[i] * plotWidth / max
+		fillRect(img, image.Rect(left, y+3, left+w, y+barHeight-3), Bar)
+		drawText(img, left+w+4, y+barHeight/2+lineHeight/3, strconv.Itoa(Values[i]))
+	}
+	return img
+}
+
+// Heatmap renders events per weekday and hour, weekday 0 is Monday. Weekdays
+// are labels of rows starting from Monday.
+func Heatmap(Values [7][24]int, Weekdays []string) image.Image {
+	cell := 28
+	left := margin
+	top := margin
+	width := left + 24*cell + margin/2
+	height := top + 7*cell + margin
+	img := newCanvas(width, height)
+
+	max := 0
+	for _, day := range Values {
+		if m := maxValue(day[:]); m > max {
+			max = m
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+	for day, hours := range Values {
+		y := top + day*cell
+		drawTextRight(img, left-4, y+cell/2+lineHeight/3, Weekdays[day])
+		for hour, count := range hours {
+			x := left + hour*cell
+			// From background to bar color by count
+			k := float64(count) / float64(max)
+			c := color.RGBA{
+				uint8(float64(Background.R) + (float64(Bar.R)-float64(Background.R))*k),
+				uint8(float64(Background.G) + (float64(Bar.G)-float64(Background.G))*k),
+				uint8(float64(Background.B) + (float64(Bar.B)-float64(Background.B))*k),
+				0xff,
+			}
+			fillRect(img, image.Rect(x+1, y+1, x+cell-1, y+cell-1), c)
+		}
+	}
+	for hour := 0; hour < 24; hour += 3 {
+		drawText(img, left+hour*cell+2, top+7*cell+lineHeight+2, strconv.Itoa(hour))
+	}
+	return img
+}
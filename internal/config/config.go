@@ -6,37 +6,91 @@ import (
 	"strings"
 )
 
+// FrigateInstance is a named Frigate server with own URLs, auth and filters
+type FrigateInstance struct {
+	Name          string
+	URL           string
+	ExternalURL   string
+	User          string
+	Password      string
+	Token         string
+	IncludeCamera []string
+	ExcludeCamera []string
+	IncludeLabel  []string
+	ExcludeLabel  []string
+	IncludeZone   []string
+	ExcludeZone   []string
+}
+
 type Config struct {
 	Debug                   bool
 	SendTextEvent           bool
 	RestAPIEnable           bool
 	ShortEventMessageFormat bool
 	IncludeThumbnailEvent   bool
+	CooldownByZone          bool
+	ReportCharts            bool
+	TelegramAPILocal        bool
+	EventPreview            bool
+	IncidentCollage         bool
 	FrigateEventLimit       int
 	SleepTime               int
 	RedisDB                 int
 	RedisProtocol           int
 	RedisTTL                int
 	WatchDogSleepTime       int
+	LoiteringThreshold      int
+	LoiteringReminder       int
+	CooldownSeconds         int
+	CorrelationWindow       int
+	FrigateMaxBacklogAge    int
+	DowntimeDigestThreshold int
+	HealthCheckInterval     int
+	ClipMaxDuration         int
+	ClipTrimSeconds         int
+	HealthInferenceSpeed    int
+	HealthStorageUsage      int
 	EventBeforeSeconds      int
 	TelegramChatID          int64
+	ReportChatID            int64
 	TelegramBotToken        string
+	TelegramAPIEndpoint     string
 	FrigateURL              string
 	FrigateExternalURL      string
 	RedisAddr               string
 	RedisPassword           string
 	RestAPIListenAddr       string
+	MessageTemplate         string
+	MessageTemplateDir      string
+	MessageTemplateFormat   string
+	Language                string
+	TimeFormat              string
+	Timezone                string
+	ReportSchedule          string
+	FFmpegPath              string
+	MediaCacheDir           string
+	ReportTime              string
+	ReportWeekday           string
 	FrigateIncludeCamera    []string
 	FrigateExcludeCamera    []string
 	FrigateExcludeLabel     []string
 	FrigateIncludeLabel     []string
 	FrigateExcludeZone      []string
 	FrigateIncludeZone      []string
+	ZoneTransitions         []string
+	LoiteringZones          []string
+	CorrelationGroups       []string
+	ClipOversizeStrategy    []string
+	EventSnapshot           []string
+	EventAnnotate           []string
+	PrivacyRegions          []string
+	MessageTemplates        []string
+	FrigateInstances        []FrigateInstance
 }
 
 // New returns a new Config struct
 func New() *Config {
-	return &Config{
+	conf := &Config{
 		TelegramBotToken:        getEnv("TELEGRAM_BOT_TOKEN", ""),
 		FrigateURL:              getEnv("FRIGATE_URL", "http://localhost:5000"),
 		FrigateEventLimit:       getEnvAsInt("FRIGATE_EVENT_LIMIT", 20),
@@ -62,7 +116,103 @@ func New() *Config {
 		ShortEventMessageFormat: getEnvAsBool("SHORT_EVENT_MESSAGE_FORMAT", false),
 		IncludeThumbnailEvent:   getEnvAsBool("INCLUDE_THUMBNAIL_EVENT", true),
 		RestAPIListenAddr:       getEnv("REST_API_LISTEN_ADDR", ":8080"),
+		ZoneTransitions:         getEnvAsSlice("ZONE_TRANSITIONS", []string{"None"}, ","),
+		LoiteringZones:          getEnvAsSlice("LOITERING_ZONES", []string{"None"}, ","),
+		LoiteringThreshold:      getEnvAsInt("LOITERING_THRESHOLD", 120),
+		LoiteringReminder:       getEnvAsInt("LOITERING_REMINDER", 600),
+		CooldownSeconds:         getEnvAsInt("COOLDOWN_SECONDS", 0),
+		CooldownByZone:          getEnvAsBool("COOLDOWN_BY_ZONE", false),
+		CorrelationGroups:       getEnvAsSlice("CORRELATION_GROUPS", []string{"None"}, ","),
+		CorrelationWindow:       getEnvAsInt("CORRELATION_WINDOW", 60),
+		FrigateMaxBacklogAge:    getEnvAsInt("FRIGATE_MAX_BACKLOG_AGE", 3600),
+		DowntimeDigestThreshold: getEnvAsInt("DOWNTIME_DIGEST_THRESHOLD", 300),
+		HealthCheckInterval:     getEnvAsInt("HEALTH_CHECK_INTERVAL", 60),
+		HealthInferenceSpeed:    getEnvAsInt("HEALTH_INFERENCE_SPEED", 100),
+		HealthStorageUsage:      getEnvAsInt("HEALTH_STORAGE_USAGE", 90),
+		ClipMaxDuration:         getEnvAsInt("CLIP_MAX_DURATION", 3600),
+		ClipOversizeStrategy:    getEnvAsSlice("CLIP_OVERSIZE_STRATEGY", []string{"link"}, ","),
+		ClipTrimSeconds:         getEnvAsInt("CLIP_TRIM_SECONDS", 30),
+		FFmpegPath:              getEnv("FFMPEG_PATH", "ffmpeg"),
+		MediaCacheDir:           getEnv("MEDIA_CACHE_DIR", "/tmp/frigate-telegram"),
+		TelegramAPIEndpoint:     getEnv("TELEGRAM_API_ENDPOINT", "https://api.telegram.org/bot%s/%s"),
+		TelegramAPILocal:        getEnvAsBool("TELEGRAM_API_LOCAL", false),
+		EventSnapshot:           getEnvAsSlice("EVENT_SNAPSHOT", []string{"None"}, ","),
+		EventPreview:            getEnvAsBool("EVENT_PREVIEW", false),
+		EventAnnotate:           getEnvAsSlice("EVENT_ANNOTATE", []string{"None"}, ","),
+		PrivacyRegions:          getEnvAsSlice("PRIVACY_REGIONS", []string{"None"}, ";"),
+		IncidentCollage:         getEnvAsBool("INCIDENT_COLLAGE", true),
+		MessageTemplate:         getEnv("MESSAGE_TEMPLATE", ""),
+		MessageTemplateDir:      getEnv("MESSAGE_TEMPLATE_DIR", ""),
+		MessageTemplateFormat:   getEnv("MESSAGE_TEMPLATE_FORMAT", "html"),
+		MessageTemplates:        getEnvAsSlice("MESSAGE_TEMPLATES", []string{"None"}, ","),
+		Language:                getEnv("LANGUAGE", "en"),
+		TimeFormat:              getEnv("TIME_FORMAT", "2006-01-02 15:04:05"),
+		Timezone:                getEnv("TIMEZONE", ""),
+		ReportSchedule:          getEnv("REPORT_SCHEDULE", "None"),
+		ReportTime:              getEnv("REPORT_TIME", "08:00"),
+		ReportWeekday:           getEnv("REPORT_WEEKDAY", "Monday"),
+		ReportChatID:            getEnvAsInt64("REPORT_CHAT_ID", 0),
+		ReportCharts:            getEnvAsBool("REPORT_CHARTS", true),
+	}
+	conf.FrigateInstances = getFrigateInstances(conf)
+	return conf
+}
+
+// Read Frigate instances from FRIGATE_INSTANCES. Every instance is configured
+// with FRIGATE_<NAME>_* variables, missing values are taken from global ones.
+// Without FRIGATE_INSTANCES single unnamed instance is used.
+func getFrigateInstances(conf *Config) []FrigateInstance {
+	defaultInstance := FrigateInstance{
+		URL:           conf.FrigateURL,
+		ExternalURL:   conf.FrigateExternalURL,
+		User:          getEnv("FRIGATE_USER", ""),
+		Password:      getEnv("FRIGATE_PASSWORD", ""),
+		Token:         getEnv("FRIGATE_TOKEN", ""),
+		IncludeCamera: conf.FrigateIncludeCamera,
+		ExcludeCamera: conf.FrigateExcludeCamera,
+		IncludeLabel:  conf.FrigateIncludeLabel,
+		ExcludeLabel:  conf.FrigateExcludeLabel,
+		IncludeZone:   conf.FrigateIncludeZone,
+		ExcludeZone:   conf.FrigateExcludeZone,
 	}
+	names := getEnvAsSlice("FRIGATE_INSTANCES", []string{}, ",")
+	if len(names) == 0 {
+		return []FrigateInstance{defaultInstance}
+	}
+
+	var instances []FrigateInstance
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		prefix := instanceEnvPrefix(name)
+		instances = append(instances, FrigateInstance{
+			Name:          name,
+			URL:           getEnv(prefix+"URL", defaultInstance.URL),
+			ExternalURL:   getEnv(prefix+"EXTERNAL_URL", defaultInstance.ExternalURL),
+			User:          getEnv(prefix+"USER", defaultInstance.User),
+			Password:      getEnv(prefix+"PASSWORD", defaultInstance.Password),
+			Token:         getEnv(prefix+"TOKEN", defaultInstance.Token),
+			IncludeCamera: getEnvAsSlice(prefix+"INCLUDE_CAMERA", defaultInstance.IncludeCamera, ","),
+			ExcludeCamera: getEnvAsSlice(prefix+"EXCLUDE_CAMERA", defaultInstance.ExcludeCamera, ","),
+			IncludeLabel:  getEnvAsSlice(prefix+"INCLUDE_LABEL", defaultInstance.IncludeLabel, ","),
+			ExcludeLabel:  getEnvAsSlice(prefix+"EXCLUDE_LABEL", defaultInstance.ExcludeLabel, ","),
+			IncludeZone:   getEnvAsSlice(prefix+"INCLUDE_ZONE", defaultInstance.IncludeZone, ","),
+			ExcludeZone:   getEnvAsSlice(prefix+"EXCLUDE_ZONE", defaultInstance.ExcludeZone, ","),
+		})
+	}
+	return instances
+}
+
+// Prefix of instance variables, name is upper cased and characters other
+// than letters and digits are replaced with `_`, e.g. `my-home` is
+// FRIGATE_MY_HOME_*
+func instanceEnvPrefix(Name string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(Name))
+	return "FRIGATE_" + name + "_"
 }
 
 // Simple helper function to read an environment or return a default value
@@ -0,0 +1,141 @@
+package frigate
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/imaging"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+// CameraMatches checks camera rule: `*`, camera name or `instance/camera`
+func CameraMatches(Rule string, Instance string, Camera string) bool {
+	return Rule == "*" || Rule == Camera || Rule == Instance+"/"+Camera
+}
+
+// AnnotateEnabled checks EVENT_ANNOTATE rules for camera
+func AnnotateEnabled(Instance string, Camera string) bool {
+	conf := config.New()
+	if len(conf.EventAnnotate) == 1 && conf.EventAnnotate[0] == "None" {
+		return false
+	}
+	for _, rule := range conf.EventAnnotate {
+		if CameraMatches(strings.TrimSpace(rule), Instance, Camera) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrivacyRegions returns relative regions [x, y, width, height] of camera
+// from PRIVACY_REGIONS rules `camera:x,y,width,height`
+func PrivacyRegions(Instance string, Camera string) [][]float64 {
+	conf := config.New()
+	var regions [][]float64
+	if len(conf.PrivacyRegions) == 1 && conf.PrivacyRegions[0] == "None" {
+		return regions
+	}
+	for _, rule := range conf.PrivacyRegions {
+		camera, box, ok := strings.Cut(strings.TrimSpace(rule), ":")
+		if !ok {
+			log.Warn.Println("Wrong privacy region rule: " + rule)
+			continue
+		}
+		if !CameraMatches(camera, Instance, Camera) {
+			continue
+		}
+		var region []float64
+		for _, value := range strings.Split(box, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				break
+			}
+			region = append(region, v)
+		}
+		if len(region) != 4 {
+			log.Warn.Println("Wrong privacy region rule: " + rule)
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// ErrPrivacyCrop is returned for cropped image of camera with privacy
+// regions, crop can't be mapped to the frame so the image must not be sent
+var ErrPrivacyCrop = errors.New("cropped image of camera with privacy regions can't be blurred")
+
+// privacyRects maps relative privacy regions to rectangles of full frame
+func privacyRects(Bounds image.Rectangle, Regions [][]float64) []image.Rectangle {
+	var rects []image.Rectangle
+	for _, region := range Regions {
+		if rect := imaging.RelativeRect(Bounds, region); !rect.Empty() {
+			rects = append(rects, rect)
+		}
+	}
+	return rects
+}
+
+// BlurPrivacy blurs PRIVACY_REGIONS of camera on full frame image. Cropped
+// image of camera with privacy regions is refused with ErrPrivacyCrop, image
+// of such camera is never returned unblurred.
+func BlurPrivacy(Instance string, Camera string, Data []byte, FullFrame bool) ([]byte, error) {
+	regions := PrivacyRegions(Instance, Camera)
+	if len(regions) == 0 {
+		return Data, nil
+	}
+	if !FullFrame {
+		return nil, ErrPrivacyCrop
+	}
+	img, err := imaging.Decode(Data)
+	if err != nil {
+		return nil, err
+	}
+	return imaging.EncodeJPEG(imaging.Annotate(img, imaging.Annotation{}, privacyRects(img.Bounds(), regions)))
+}
+
+// AnnotateEventImage draws box, label, camera, zones and time on image of
+// event. Box needs full frame, it is skipped on thumbnail and cropped
+// snapshot. Privacy regions must be already blurred by BlurPrivacy.
+func AnnotateEventImage(FrigateEvent EventStruct, Data []byte, FullFrame bool) []byte {
+	if !AnnotateEnabled(FrigateEvent.Instance, FrigateEvent.Camera) {
+		return Data
+	}
+	img, err := imaging.Decode(Data)
+	if err != nil {
+		log.Warn.Println("Error decoding image of event " + FrigateEvent.ID + ": " + err.Error())
+		return Data
+	}
+	b := img.Bounds()
+
+	var annotation imaging.Annotation
+	if FullFrame {
+		annotation.Box = imaging.RelativeRect(b, FrigateEvent.Data.Box)
+	}
+	annotation.Label = fmt.Sprintf("%s %.0f%%", FrigateEvent.Label, FrigateEvent.Data.TopScore*100)
+	camera := FrigateEvent.Camera
+	if FrigateEvent.Instance != "" {
+		camera = FrigateEvent.Instance + "/" + camera
+	}
+	annotation.Lines = append(annotation.Lines, camera)
+	if zones := GetTagList(FrigateEvent.Zones); len(zones) != 0 {
+		annotation.Lines = append(annotation.Lines, strings.Join(zones, ", "))
+	}
+	annotation.Lines = append(annotation.Lines, i18n.FormatTime(time.Unix(int64(FrigateEvent.StartTime), 0)))
+	if annotation.Box.Empty() {
+		annotation.Lines = append(annotation.Lines, annotation.Label)
+	}
+
+	data, err := imaging.EncodeJPEG(imaging.Annotate(img, annotation, nil))
+	if err != nil {
+		log.Warn.Println("Error encoding image of event " + FrigateEvent.ID + ": " + err.Error())
+		return Data
+	}
+	return data
+}
@@ -0,0 +1,73 @@
+package frigate
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/oldtyt/frigate-telegram/internal/imaging"
+)
+
+func TestPrivacyRects(t *testing.T) {
+	bounds := image.Rect(0, 0, 1280, 720)
+	got := privacyRects(bounds, [][]float64{{0.5, 0, 0.5, 0.5}, {0.9, 0.9, 0.5, 0.5}, {2, 2, 0.1, 0.1}})
+	want := []image.Rectangle{
+		image.Rect(640, 0, 1280, 360),
+		// Region out of frame is clipped
+		image.Rect(1152, 648, 1280, 720),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// testFrame returns JPEG with white left half and black right half
+func testFrame(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			if x < 32 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	data, err := imaging.EncodeJPEG(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBlurPrivacy(t *testing.T) {
+	frame := testFrame(t)
+	t.Setenv("PRIVACY_REGIONS", "None")
+	data, err := BlurPrivacy("", "porch", frame, false)
+	if err != nil || !bytes.Equal(data, frame) {
+		t.Fatalf("got error %v, want image of camera without privacy regions as is", err)
+	}
+
+	t.Setenv("PRIVACY_REGIONS", "porch:0.25,0,0.5,1")
+	if _, err := BlurPrivacy("", "porch", frame, false); !errors.Is(err, ErrPrivacyCrop) {
+		t.Fatalf("got error %v, want cropped image refused", err)
+	}
+	data, err = BlurPrivacy("", "porch", frame, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := imaging.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Edge between white and black halves is blurred to gray
+	if r, _, _, _ := img.At(31, 16).RGBA(); r>>8 > 0xe0 || r>>8 < 0x20 {
+		t.Errorf("got pixel %d at edge inside privacy region, want blurred", r>>8)
+	}
+	if r, _, _, _ := img.At(2, 16).RGBA(); r>>8 < 0xe0 {
+		t.Errorf("got pixel %d outside privacy region, want white", r>>8)
+	}
+}
@@ -0,0 +1,156 @@
+package frigate
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+)
+
+// FrigateCameraConfig is camera part of /api/config used by bot
+type FrigateCameraConfig struct {
+	Enabled bool `json:"enabled"`
+	Detect  struct {
+		Enabled bool `json:"enabled"`
+		Width   int  `json:"width"`
+		Height  int  `json:"height"`
+		FPS     int  `json:"fps"`
+	} `json:"detect"`
+	Record struct {
+		Enabled bool `json:"enabled"`
+	} `json:"record"`
+	Snapshots struct {
+		Enabled bool `json:"enabled"`
+	} `json:"snapshots"`
+	Objects struct {
+		Track []string `json:"track"`
+	} `json:"objects"`
+	Zones map[string]interface{} `json:"zones"`
+}
+
+// FrigateConfigStruct is part of /api/config used by bot
+type FrigateConfigStruct struct {
+	Cameras map[string]FrigateCameraConfig `json:"cameras"`
+}
+
+// FrigateCameraStats is camera part of /api/stats
+type FrigateCameraStats struct {
+	CameraFPS    float64 `json:"camera_fps"`
+	ProcessFPS   float64 `json:"process_fps"`
+	SkippedFPS   float64 `json:"skipped_fps"`
+	DetectionFPS float64 `json:"detection_fps"`
+	Pid          int     `json:"pid"`
+	CapturePid   int     `json:"capture_pid"`
+}
+
+// FrigateStatsStruct is part of /api/stats used by bot
+type FrigateStatsStruct struct {
+	Cameras   map[string]FrigateCameraStats `json:"cameras"`
+	Detectors map[string]struct {
+		InferenceSpeed float64 `json:"inference_speed"`
+		Pid            int     `json:"pid"`
+	} `json:"detectors"`
+	Service struct {
+		Uptime  int64  `json:"uptime"`
+		Version string `json:"version"`
+		Storage map[string]struct {
+			Total     float64 `json:"total"`
+			Used      float64 `json:"used"`
+			Free      float64 `json:"free"`
+			MountType string  `json:"mount_type"`
+		} `json:"storage"`
+	} `json:"service"`
+}
+
+// GetFrigateStats returns stats of Frigate instance
+func GetFrigateStats(Instance config.FrigateInstance) (FrigateStatsStruct, error) {
+	var stats FrigateStatsStruct
+	data, err := GetFrigateBytes(Instance, Instance.URL+"/api/stats")
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(data, &stats)
+	return stats, err
+}
+
+// CameraOnline checks camera receives frames
+func CameraOnline(Stats FrigateCameraStats) bool {
+	return Stats.CameraFPS > 0
+}
+
+// GetFrigateConfig returns config of Frigate instance
+func GetFrigateConfig(Instance config.FrigateInstance) (FrigateConfigStruct, error) {
+	var frigateConfig FrigateConfigStruct
+	data, err := GetFrigateBytes(Instance, Instance.URL+"/api/config")
+	if err != nil {
+		return frigateConfig, err
+	}
+	err = json.Unmarshal(data, &frigateConfig)
+	return frigateConfig, err
+}
+
+// GetCameras returns sorted camera names of Frigate instance
+func GetCameras(Instance config.FrigateInstance) ([]string, error) {
+	frigateConfig, err := GetFrigateConfig(Instance)
+	if err != nil {
+		return nil, err
+	}
+	var cameras []string
+	for camera := range frigateConfig.Cameras {
+		cameras = append(cameras, camera)
+	}
+	sort.Strings(cameras)
+	return cameras, nil
+}
+
+// FindCamera returns instance of camera. Name can be `instance/camera`,
+// otherwise camera is searched in all instances.
+func FindCamera(Name string) (config.FrigateInstance, string, error) {
+	conf := config.New()
+	if instanceName, camera, ok := strings.Cut(Name, "/"); ok {
+		for _, instance := range conf.FrigateInstances {
+			if instance.Name == instanceName {
+				return instance, camera, nil
+			}
+		}
+		return conf.FrigateInstances[0], camera, i18n.NewError("unknown_instance", instanceName)
+	}
+	if len(conf.FrigateInstances) == 1 {
+		return conf.FrigateInstances[0], Name, nil
+	}
+	for _, instance := range conf.FrigateInstances {
+		cameras, err := GetCameras(instance)
+		if err != nil {
+			continue
+		}
+		if StringsContains(Name, cameras) {
+			return instance, Name, nil
+		}
+	}
+	return conf.FrigateInstances[0], Name, i18n.NewError("unknown_camera", Name)
+}
+
+// CameraName returns camera name with instance prefix for multiple instances
+func CameraName(Instance config.FrigateInstance, Camera string) string {
+	if Instance.Name == "" {
+		return Camera
+	}
+	return Instance.Name + "/" + Camera
+}
+
+// GetLatestSnapshot returns latest frame of camera with blurred privacy
+// regions. Supported params are bbox, height and quality of Frigate API.
+func GetLatestSnapshot(Instance config.FrigateInstance, Camera string, Params url.Values) ([]byte, error) {
+	URL := Instance.URL + "/api/" + url.PathEscape(Camera) + "/latest.jpg"
+	if len(Params) != 0 {
+		URL += "?" + Params.Encode()
+	}
+	data, err := GetFrigateBytes(Instance, URL)
+	if err != nil {
+		return nil, err
+	}
+	return BlurPrivacy(Instance.Name, Camera, data, true)
+}
@@ -0,0 +1,89 @@
+package frigate
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/imaging"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+// Cell of camera collage, camera frames are mostly 16:9
+const (
+	CollageCellWidth  = 480
+	CollageCellHeight = 270
+	// Max count of cameras in one collage
+	CollageLimit = 16
+)
+
+// SnapshotTile returns latest snapshot of camera as collage tile
+func SnapshotTile(Instance config.FrigateInstance, Camera string, Params url.Values) (imaging.Tile, error) {
+	tile := imaging.Tile{Label: CameraName(Instance, Camera)}
+	data, err := GetLatestSnapshot(Instance, Camera, Params)
+	if err != nil {
+		return tile, err
+	}
+	tile.Image, err = imaging.Decode(data)
+	return tile, err
+}
+
+// EncodeCollage renders camera tiles into single JPEG grid
+func EncodeCollage(Tiles []imaging.Tile) ([]byte, error) {
+	return imaging.EncodeJPEG(imaging.Grid(Tiles, imaging.GridColumns(len(Tiles)), CollageCellWidth, CollageCellHeight))
+}
+
+// IncidentCollage tiles image of event with images of other events of
+// incident, labeled with camera and time. Image is returned as is if it
+// can't be rendered.
+func IncidentCollage(FrigateEvent EventStruct, Image []byte, Events []string) []byte {
+	img, err := imaging.Decode(Image)
+	if err != nil {
+		log.Warn.Println("Error decoding image of event " + FrigateEvent.ID + ": " + err.Error())
+		return Image
+	}
+	instance := GetInstance(FrigateEvent.Instance)
+	tiles := []imaging.Tile{{Image: img, Label: eventTileLabel(instance, FrigateEvent)}}
+	for _, id := range Events {
+		if id == FrigateEvent.ID || len(tiles) == CollageLimit {
+			continue
+		}
+		tile, err := EventTile(instance, id)
+		if err != nil {
+			log.Warn.Println("Error getting image of event " + id + " for incident: " + err.Error())
+			continue
+		}
+		tiles = append(tiles, tile)
+	}
+	if len(tiles) == 1 {
+		return Image
+	}
+	data, err := EncodeCollage(tiles)
+	if err != nil {
+		log.Warn.Println("Error encoding incident collage: " + err.Error())
+		return Image
+	}
+	return data
+}
+
+// EventTile returns thumbnail of event as collage tile, full snapshot for
+// camera with privacy regions
+func EventTile(Instance config.FrigateInstance, EventID string) (imaging.Tile, error) {
+	var tile imaging.Tile
+	event, err := GetEvent(Instance, EventID)
+	if err != nil {
+		return tile, err
+	}
+	tile.Label = eventTileLabel(Instance, event)
+	data, _, err := GetEventThumbnail(event)
+	if err != nil {
+		return tile, err
+	}
+	tile.Image, err = imaging.Decode(data)
+	return tile, err
+}
+
+func eventTileLabel(Instance config.FrigateInstance, FrigateEvent EventStruct) string {
+	return CameraName(Instance, FrigateEvent.Camera) + " " + i18n.LocalTime(time.Unix(int64(FrigateEvent.StartTime), 0)).Format("15:04:05")
+}
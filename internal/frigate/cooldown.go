@@ -0,0 +1,115 @@
+package frigate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/history"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+const (
+	CooldownMessageCaption = "caption"
+	CooldownMessageText    = "text"
+)
+
+// CooldownKey returns state key for camera+label, optional with zones
+func CooldownKey(FrigateEvent EventStruct) string {
+	conf := config.New()
+	key := "Cooldown_" + InstanceKey(FrigateEvent.Instance, FrigateEvent.Camera) + "_" + FrigateEvent.Label
+	if conf.CooldownByZone {
+		zones := GetZoneList(FrigateEvent.Zones)
+		sort.Strings(zones)
+		key += "_" + strings.Join(zones, ",")
+	}
+	return key
+}
+
+// CollapseEvent returns true if event got into cooldown window of previous
+// notification. In this case the previous message is edited instead of
+// sending new one. First event of the window opens it and must be sent.
+func CollapseEvent(FrigateEvent EventStruct, bot *tgbotapi.BotAPI) bool {
+	conf := config.New()
+	if conf.CooldownSeconds <= 0 {
+		return false
+	}
+	// Updates of already sent events are not collapsed
+	if redis.ExistsEvent(EventKey(FrigateEvent)) {
+		return false
+	}
+
+	window := time.Duration(conf.CooldownSeconds) * time.Second
+	key := CooldownKey(FrigateEvent)
+	state := redis.GetEventState(key)
+	if len(state) == 0 {
+		redis.SetEventState(key, map[string]string{"owner": EventKey(FrigateEvent), "msg": "0", "count": "0"}, window)
+		return false
+	}
+
+	count := redis.IncrEventState(key, "count", window)
+	redis.AddNewEvent(EventKey(FrigateEvent), "Finished", time.Duration(conf.RedisTTL)*time.Second)
+	log.Debug.Println("Collapsing event " + FrigateEvent.ID + " into cooldown window of " + state["owner"])
+	msgID, _ := strconv.Atoi(state["msg"])
+	// Event is counted in text of the first message of cooldown window
+	var messages []history.Message
+	if msgID != 0 {
+		messages = []history.Message{{Chat: conf.TelegramChatID, ID: msgID, Kind: history.MediaText}}
+	}
+	RecordHistory(FrigateEvent, history.StatusCollapsed, "cooldown of "+state["owner"], messages)
+
+	state["count"] = strconv.FormatInt(count, 10)
+	EditCollapsedMessage(state, bot)
+	return true
+}
+
+// SaveCooldownMessage remembers message of the event which opened cooldown
+// window, so next events can be collapsed into it.
+func SaveCooldownMessage(FrigateEvent EventStruct, MessageID int, Kind string, Text string, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	if conf.CooldownSeconds <= 0 {
+		return
+	}
+	key := CooldownKey(FrigateEvent)
+	state := redis.GetEventState(key)
+	if state["owner"] != EventKey(FrigateEvent) {
+		return
+	}
+	state["msg"] = strconv.Itoa(MessageID)
+	state["kind"] = Kind
+	state["text"] = Text
+	redis.SetEventState(key, map[string]string{"msg": state["msg"], "kind": Kind, "text": Text}, 0)
+
+	// Events collapsed while message was sending
+	EditCollapsedMessage(state, bot)
+}
+
+// EditCollapsedMessage appends collapsed events counter to message
+func EditCollapsedMessage(State map[string]string, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	msgID, _ := strconv.Atoi(State["msg"])
+	if msgID == 0 || State["count"] == "0" {
+		return
+	}
+	text := State["text"] + "\n<b>" + i18n.T(i18n.Language(conf.TelegramChatID), "more_detections", State["count"]) + "</b>"
+
+	var edit tgbotapi.Chattable
+	if State["kind"] == CooldownMessageCaption {
+		caption := tgbotapi.NewEditMessageCaption(conf.TelegramChatID, msgID, text)
+		caption.ParseMode = markup.ParseMode
+		edit = caption
+	} else {
+		message := tgbotapi.NewEditMessageText(conf.TelegramChatID, msgID, text)
+		message.ParseMode = markup.ParseMode
+		edit = message
+	}
+	if _, err := markup.Request(bot, edit); err != nil {
+		log.Error.Println("Error editing collapsed message: " + err.Error())
+	}
+}
@@ -0,0 +1,158 @@
+package frigate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+// CorrelationGroup returns group of neighbouring cameras which contains camera.
+// Groups are configured in CORRELATION_GROUPS, cameras in group separate `:`.
+func CorrelationGroup(Camera string) string {
+	conf := config.New()
+	if len(conf.CorrelationGroups) == 1 && conf.CorrelationGroups[0] == "None" {
+		return ""
+	}
+	for _, group := range conf.CorrelationGroups {
+		if StringsContains(Camera, strings.Split(strings.TrimSpace(group), ":")) {
+			return strings.TrimSpace(group)
+		}
+	}
+	return ""
+}
+
+func IncidentKey(Group string, Label string) string {
+	return "Incident_" + Group + "_" + Label
+}
+
+// Message of incident is saved after upload, next event of incident waits
+// for it to be sent as reply
+var IncidentMessageWait = 30 * time.Second
+
+// CorrelateEvent joins event to incident with the same label on neighbouring
+// cameras within CORRELATION_WINDOW or starts new incident. Incident is
+// updated atomically before media is downloaded, so concurrent events of the
+// group join one incident. Returns message ID to reply to, path of cameras
+// and IDs of incident events, both including the event.
+func CorrelateEvent(FrigateEvent EventStruct) (int, []string, []string) {
+	conf := config.New()
+	group := CorrelationGroup(FrigateEvent.Camera)
+	if group == "" {
+		return 0, nil, nil
+	}
+	key := IncidentKey(InstanceKey(FrigateEvent.Instance, group), FrigateEvent.Label)
+	var path []string
+	started := false
+	state := redis.UpdateEventState(key, func(state map[string]string) map[string]string {
+		path, started = nil, false
+		var events []string
+		if state["events"] != "" {
+			events = strings.Split(state["events"], ",")
+		}
+		// Update of event which is already part of incident
+		if StringsContains(EventKey(FrigateEvent), events) {
+			path = strings.Split(state["path"], ",")
+			return nil
+		}
+		// Update of event which is not part of current incident
+		if redis.ExistsEvent(EventKey(FrigateEvent)) {
+			return nil
+		}
+		// Window is counted from the latest event of incident
+		lastTime, _ := strconv.ParseFloat(state["time"], 64)
+		if len(state) == 0 || FrigateEvent.StartTime-lastTime > float64(conf.CorrelationWindow) {
+			path, started = []string{FrigateEvent.Camera}, true
+			// Message is unknown until event is sent
+			return map[string]string{
+				"msg":    "0",
+				"path":   FrigateEvent.Camera,
+				"time":   strconv.FormatFloat(FrigateEvent.StartTime, 'f', -1, 64),
+				"events": EventKey(FrigateEvent),
+			}
+		}
+		path = strings.Split(state["path"], ",")
+		if path[len(path)-1] != FrigateEvent.Camera {
+			path = append(path, FrigateEvent.Camera)
+		}
+		if FrigateEvent.StartTime > lastTime {
+			lastTime = FrigateEvent.StartTime
+		}
+		return map[string]string{
+			"path":   strings.Join(path, ","),
+			"time":   strconv.FormatFloat(lastTime, 'f', -1, 64),
+			"events": strings.Join(append(events, EventKey(FrigateEvent)), ","),
+		}
+	}, time.Duration(conf.RedisTTL)*time.Second)
+	if path == nil {
+		return 0, nil, nil
+	}
+	var events []string
+	for _, event := range strings.Split(state["events"], ",") {
+		events = append(events, strings.TrimPrefix(event, InstanceKey(FrigateEvent.Instance, "")))
+	}
+	if started {
+		return 0, path, events
+	}
+	log.Debug.Println("Event " + FrigateEvent.ID + " correlated with incident: " + strings.Join(path, " → "))
+	return waitIncidentMessage(key, state), path, events
+}
+
+// incidentFailed is message of incident which first event wasn't sent
+const incidentFailed = "-1"
+
+// waitIncidentMessage returns last message of incident, waits while the
+// first event of incident is being sent. Event of failed incident is sent
+// without reply at once.
+func waitIncidentMessage(Key string, State map[string]string) int {
+	deadline := time.Now().Add(IncidentMessageWait)
+	for {
+		if State["msg"] == incidentFailed {
+			return 0
+		}
+		replyTo, _ := strconv.Atoi(State["msg"])
+		if replyTo != 0 || time.Now().After(deadline) {
+			return replyTo
+		}
+		time.Sleep(time.Second)
+		State = redis.GetEventState(Key)
+	}
+}
+
+// SaveIncident stores last message of incident thread, nil path is ignored
+func SaveIncident(FrigateEvent EventStruct, MessageID int, Path []string) {
+	conf := config.New()
+	group := CorrelationGroup(FrigateEvent.Camera)
+	if group == "" || Path == nil {
+		return
+	}
+	key := IncidentKey(InstanceKey(FrigateEvent.Instance, group), FrigateEvent.Label)
+	redis.UpdateEventState(key, func(state map[string]string) map[string]string {
+		// Incident is already replaced by new one
+		if !StringsContains(EventKey(FrigateEvent), strings.Split(state["events"], ",")) {
+			return nil
+		}
+		// Events are compared by start time, so state lives as long as events
+		return map[string]string{"msg": strconv.Itoa(MessageID)}
+	}, time.Duration(conf.RedisTTL)*time.Second)
+}
+
+// FailIncident marks incident which message is still unknown as failed, so
+// next events of incident don't wait for it. Nil path is ignored.
+func FailIncident(FrigateEvent EventStruct, Path []string) {
+	conf := config.New()
+	group := CorrelationGroup(FrigateEvent.Camera)
+	if group == "" || Path == nil {
+		return
+	}
+	key := IncidentKey(InstanceKey(FrigateEvent.Instance, group), FrigateEvent.Label)
+	redis.UpdateEventState(key, func(state map[string]string) map[string]string {
+		if state["msg"] != "0" || !StringsContains(EventKey(FrigateEvent), strings.Split(state["events"], ",")) {
+			return nil
+		}
+		return map[string]string{"msg": incidentFailed}
+	}, time.Duration(conf.RedisTTL)*time.Second)
+}
@@ -0,0 +1,271 @@
+package frigate
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/history"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+// Frigate filters events by start time with exclusive bounds. Next page
+// is requested before start time of the oldest event plus this offset, so
+// events sharing start time on page boundary are not lost.
+const pageOverlap = 0.001
+
+// GetEventsRange pages through all events started between After and Before,
+// events are counted without Thumbnails, e.g. for reports
+func GetEventsRange(Instance config.FrigateInstance, After float64, Before float64, Thumbnails bool) (EventsStruct, error) {
+	return pageEvents(config.New().FrigateEventLimit, After, Before, func(After float64, Before float64) (EventsStruct, error) {
+		return GetEvents(Instance, After, Before, Thumbnails)
+	})
+}
+
+// pageEvents requests pages of Limit events newest first with Fetch until
+// range is exhausted. Pages overlap, events are deduplicated by ID. Error
+// of any page fails whole range, so cursor isn't moved past missed events.
+func pageEvents(Limit int, After float64, Before float64, Fetch func(After float64, Before float64) (EventsStruct, error)) (EventsStruct, error) {
+	var events EventsStruct
+	seen := map[string]bool{}
+	for {
+		page, err := Fetch(After, Before)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, event := range page {
+			if seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			events = append(events, event)
+			added++
+		}
+		if len(page) < Limit || added == 0 {
+			if len(page) >= Limit {
+				log.Warn.Printf("More than %d events have the same start time, some of them are skipped", Limit)
+			}
+			break
+		}
+		// Events are sorted by start time desc, next page ends at the oldest one
+		oldest := page[len(page)-1].StartTime
+		for _, event := range page {
+			if event.StartTime < oldest {
+				oldest = event.StartTime
+			}
+		}
+		Before = oldest + pageOverlap
+	}
+	return events, nil
+}
+
+func CursorKey(Instance config.FrigateInstance, WatchDog bool) string {
+	if WatchDog {
+		return InstanceKey(Instance.Name, "WatchDog")
+	}
+	return InstanceKey(Instance.Name, "Events")
+}
+
+// RunningKey is key of events in progress of instance checked by ID
+func RunningKey(Instance config.FrigateInstance) string {
+	return InstanceKey(Instance.Name, "Running")
+}
+
+// GetNewEvents returns events started after the saved cursor and updates of
+// events in progress. Events older than FRIGATE_MAX_BACKLOG_AGE are not sent
+// one by one, main loop sends summary of them instead. Fresh install without
+// cursor starts from now. Cursor is moved only if all pages are received.
+func GetNewEvents(Instance config.FrigateInstance, bot *tgbotapi.BotAPI, WatchDog bool) (EventsStruct, error) {
+	conf := config.New()
+	key := CursorKey(Instance, WatchDog)
+	now := float64(time.Now().UTC().Unix())
+	cutoff := now - float64(conf.FrigateMaxBacklogAge)
+	before := float64(0)
+	if !WatchDog {
+		before = now - float64(conf.EventBeforeSeconds)
+	}
+
+	cursor, ok := redis.GetEventsCursor(key)
+	if !ok {
+		log.Info.Println("No saved cursor for " + key + ", starting from now")
+		redis.SetEventsCursor(key, now)
+		return nil, nil
+	}
+	if cursor < cutoff {
+		if !WatchDog {
+			backlog, err := GetEventsRange(Instance, cursor, cutoff, false)
+			if err != nil {
+				return nil, err
+			}
+			SendBacklogSummary(Instance, backlog, bot)
+		}
+		cursor = cutoff
+		redis.SetEventsCursor(key, cursor)
+	}
+
+	events, err := GetEventsRange(Instance, cursor, before, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if WatchDog {
+		redis.SetEventsCursor(key, nextCursor(cursor, events))
+		return events, nil
+	}
+
+	// Events in progress newer than EVENT_BEFORE_SECONDS are received without
+	// before bound, so zones and preview are handled without delay
+	recent, err := GetEventsRange(Instance, math.Max(cursor, before-pageOverlap), 0, true)
+	if err != nil {
+		return nil, err
+	}
+	running := runningEvents(Instance, events)
+	// Cursor is moved past events in progress, they are checked by ID
+	for _, event := range events {
+		if event.EndTime == 0 {
+			redis.AddInProgress(RunningKey(Instance), event.ID, time.Duration(conf.RedisTTL)*time.Second)
+		}
+	}
+	redis.SetEventsCursor(key, nextCursor(cursor, events))
+
+	events = append(events, running...)
+	return append(events, recentInProgress(recent, events)...), nil
+}
+
+// runningEvents requests events in progress saved on previous polls which
+// are not in Events by ID. Finished and deleted events are not checked
+// anymore, finished event is returned once more to send its end.
+func runningEvents(Instance config.FrigateInstance, Events EventsStruct) EventsStruct {
+	key := RunningKey(Instance)
+	received := map[string]EventStruct{}
+	for _, event := range Events {
+		received[event.ID] = event
+	}
+	var events EventsStruct
+	for _, id := range redis.GetInProgress(key) {
+		event, ok := received[id]
+		if !ok {
+			var err error
+			event, err = GetEvent(Instance, id)
+			if errors.Is(err, ErrNotFound) {
+				redis.RemoveInProgress(key, id)
+				continue
+			}
+			if err != nil {
+				log.Warn.Println("Error getting event in progress " + id + ": " + err.Error())
+				continue
+			}
+			events = append(events, event)
+		}
+		if event.EndTime != 0 {
+			redis.RemoveInProgress(key, id)
+		}
+	}
+	return events
+}
+
+// recentInProgress returns recent events in progress which are not in
+// Events. They don't move cursor, finished event is received again when it
+// is older than EVENT_BEFORE_SECONDS.
+func recentInProgress(Recent EventsStruct, Events EventsStruct) EventsStruct {
+	seen := map[string]bool{}
+	for _, event := range Events {
+		seen[event.ID] = true
+	}
+	var events EventsStruct
+	for _, event := range Recent {
+		if event.EndTime == 0 && !seen[event.ID] {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Pending checks whether event is newer than EVENT_BEFORE_SECONDS, such
+// event is received for zones and preview only
+func Pending(FrigateEvent EventStruct, Now time.Time) bool {
+	return FrigateEvent.EndTime == 0 && FrigateEvent.StartTime > float64(Now.Unix()-int64(config.New().EventBeforeSeconds))
+}
+
+// nextCursor returns start time of the newest event, events in progress
+// don't hold cursor
+func nextCursor(Cursor float64, FrigateEvents EventsStruct) float64 {
+	next := Cursor
+	for _, event := range FrigateEvents {
+		if event.StartTime > next {
+			next = event.StartTime
+		}
+	}
+	return next
+}
+
+// SendBacklogSummary sends counts of events which are too old to be sent
+// one by one and marks them as processed.
+func SendBacklogSummary(Instance config.FrigateInstance, FrigateEvents EventsStruct, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	counts := map[string]map[string]int{}
+	total := 0
+	for _, event := range FrigateEvents {
+		if redis.ExistsEvent(EventKey(event)) {
+			continue
+		}
+		if counts[event.Camera] == nil {
+			counts[event.Camera] = map[string]int{}
+		}
+		counts[event.Camera][event.Label]++
+		total++
+		redis.AddNewEvent(EventKey(event), "Finished", time.Duration(conf.RedisTTL)*time.Second)
+		RecordHistory(event, history.StatusBacklog, "older than max backlog age", nil)
+	}
+	if total == 0 {
+		return
+	}
+
+	lang := i18n.Language(conf.TelegramChatID)
+	text := "<b>" + i18n.T(lang, "skipped_backlog", total) + "</b> " +
+		markup.Code(i18n.FormatDuration(lang, time.Duration(conf.FrigateMaxBacklogAge)*time.Second))
+	if Instance.Name != "" {
+		text += " #" + NormalizeTagText(Instance.Name)
+	}
+	text += "\n" + FormatEventCounts(counts)
+
+	msg := tgbotapi.NewMessage(conf.TelegramChatID, text)
+	msg.ParseMode = markup.ParseMode
+	msg.DisableNotification = redis.GetStateMuteEvent()
+	if _, err := markup.Send(bot, msg); err != nil {
+		log.Error.Println(err.Error())
+	}
+}
+
+// FormatEventCounts formats counts per camera and label, sorted by camera
+func FormatEventCounts(Counts map[string]map[string]int) string {
+	var cameras []string
+	for camera := range Counts {
+		cameras = append(cameras, camera)
+	}
+	sort.Strings(cameras)
+
+	text := ""
+	for i, camera := range cameras {
+		var labels []string
+		for label, count := range Counts[camera] {
+			labels = append(labels, "#"+NormalizeTagText(label)+" "+strconv.Itoa(count))
+		}
+		sort.Strings(labels)
+		prefix := "┣"
+		if i == len(cameras)-1 {
+			prefix = "┗"
+		}
+		text += prefix + "#" + NormalizeTagText(camera) + ": " + strings.Join(labels, ", ") + "\n"
+	}
+	return text
+}
@@ -0,0 +1,141 @@
+package frigate
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeFrigate returns fetch with filtering and ordering of Frigate events API
+func fakeFrigate(Limit int, Events EventsStruct, Fail int) (func(float64, float64) (EventsStruct, error), *int) {
+	calls := 0
+	return func(After float64, Before float64) (EventsStruct, error) {
+		calls++
+		if calls == Fail {
+			return nil, errors.New("connection reset")
+		}
+		var page EventsStruct
+		for _, event := range Events {
+			if (After == 0 || event.StartTime > After) && (Before == 0 || event.StartTime < Before) {
+				page = append(page, event)
+			}
+		}
+		sort.SliceStable(page, func(i, j int) bool { return page[i].StartTime > page[j].StartTime })
+		if len(page) > Limit {
+			page = page[:Limit]
+		}
+		return page, nil
+	}, &calls
+}
+
+func testEvents(StartTimes ...float64) EventsStruct {
+	var events EventsStruct
+	for i, start := range StartTimes {
+		events = append(events, EventStruct{ID: string(rune('a' + i)), StartTime: start, EndTime: start + 5})
+	}
+	return events
+}
+
+func eventIDs(Events EventsStruct) []string {
+	var ids []string
+	for _, event := range Events {
+		ids = append(ids, event.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestPageEventsSharedStartTime(t *testing.T) {
+	// Page boundary falls between events c and d with the same start time
+	events := testEvents(10, 20, 30, 30, 40, 50)
+	fetch, _ := fakeFrigate(3, events, 0)
+	got, err := pageEvents(3, 0, 100, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := eventIDs(events); !reflect.DeepEqual(eventIDs(got), want) {
+		t.Fatalf("got events %v, want every event once %v", eventIDs(got), want)
+	}
+}
+
+func TestPageEventsRange(t *testing.T) {
+	fetch, _ := fakeFrigate(2, testEvents(10, 20, 30, 40, 50), 0)
+	got, err := pageEvents(2, 10, 50, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"b", "c", "d"}
+	if !reflect.DeepEqual(eventIDs(got), want) {
+		t.Fatalf("got events %v, want %v", eventIDs(got), want)
+	}
+}
+
+func TestPageEventsError(t *testing.T) {
+	fetch, calls := fakeFrigate(2, testEvents(10, 20, 30, 40, 50), 2)
+	got, err := pageEvents(2, 0, 100, fetch)
+	if err == nil {
+		t.Fatalf("got %d events without error, want error of second page", len(got))
+	}
+	if *calls != 2 {
+		t.Fatalf("got %d requests, want paging stopped on error", *calls)
+	}
+}
+
+func TestPageEventsSameStartTimeOverLimit(t *testing.T) {
+	// Paging stops when next page has no new events
+	fetch, calls := fakeFrigate(2, testEvents(30, 30, 30), 0)
+	if _, err := pageEvents(2, 0, 100, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if *calls > 2 {
+		t.Fatalf("got %d requests, want paging stopped without progress", *calls)
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	events := testEvents(10, 20, 30)
+	if got := nextCursor(5, events); got != 30 {
+		t.Errorf("got cursor %v, want start of the newest event 30", got)
+	}
+	if got := nextCursor(40, events); got != 40 {
+		t.Errorf("got cursor %v, want cursor 40 not moved back", got)
+	}
+	if got := nextCursor(40, nil); got != 40 {
+		t.Errorf("got cursor %v without events, want 40", got)
+	}
+	events[1].EndTime = 0
+	if got := nextCursor(5, events); got != 30 {
+		t.Errorf("got cursor %v, want cursor moved past event in progress to 30", got)
+	}
+}
+
+func TestRecentInProgress(t *testing.T) {
+	recent := testEvents(10, 20, 30, 40)
+	recent[1].EndTime = 0
+	recent[2].EndTime = 0
+	recent[3].EndTime = 0
+	events := EventsStruct{recent[2]}
+	got := recentInProgress(recent, events)
+	if want := []string{"b", "d"}; !reflect.DeepEqual(eventIDs(got), want) {
+		t.Fatalf("got events %v, want events in progress missing in events %v", eventIDs(got), want)
+	}
+}
+
+func TestPending(t *testing.T) {
+	t.Setenv("EVENT_BEFORE_SECONDS", "300")
+	now := time.Unix(1000, 0)
+	event := EventStruct{StartTime: 900}
+	if !Pending(event, now) {
+		t.Error("got event in progress newer than EVENT_BEFORE_SECONDS not pending")
+	}
+	event.EndTime = 950
+	if Pending(event, now) {
+		t.Error("got finished event pending")
+	}
+	event = EventStruct{StartTime: 600}
+	if Pending(event, now) {
+		t.Error("got event older than EVENT_BEFORE_SECONDS pending")
+	}
+}
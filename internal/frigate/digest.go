@@ -0,0 +1,141 @@
+package frigate
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/history"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/imaging"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+// Max thumbnails in digest collage
+var DigestThumbnailsLimit int = 16
+
+// digestWindow returns range of events missed while bot was down. Main loop
+// receives events older than EVENT_BEFORE_SECONDS, so range starts at saved
+// cursor of main loop or before last heartbeat if cursor is not saved.
+func digestWindow(Heartbeat int64, Cursor float64, HasCursor bool, EventBeforeSeconds int, Now int64) (float64, float64) {
+	from := float64(Heartbeat - int64(EventBeforeSeconds))
+	if HasCursor {
+		from = Cursor
+	}
+	return from, float64(Now)
+}
+
+// SendDowntimeDigest checks gap since last heartbeat. If the bot was down
+// longer than DOWNTIME_DIGEST_THRESHOLD, events of the gap are marked as
+// processed and single digest is sent instead of separate messages.
+// Must be called before polling is started.
+func SendDowntimeDigest(bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	last, ok := redis.GetHeartbeat()
+	redis.SetHeartbeat()
+	if !ok {
+		return
+	}
+	now := time.Now().UTC().Unix()
+	gap := time.Duration(now-last) * time.Second
+	if gap < time.Duration(conf.DowntimeDigestThreshold)*time.Second {
+		return
+	}
+	log.Info.Println("Bot was down for " + gap.String() + ", preparing digest")
+
+	counts := map[string]map[string]int{}
+	total := 0
+	var thumbnails []imaging.Tile
+	for _, instance := range conf.FrigateInstances {
+		cursor, hasCursor := redis.GetEventsCursor(CursorKey(instance, false))
+		from, to := digestWindow(last, cursor, hasCursor, conf.EventBeforeSeconds, now)
+		events, err := GetEventsRange(instance, from, to, true)
+		if err != nil {
+			// Polling catches up missed events of instance from saved cursor
+			log.Warn.Println("Error getting events for digest: " + err.Error())
+			continue
+		}
+		for _, event := range events {
+			if SkipEventReason(event) != "" {
+				continue
+			}
+			// Event in progress is checked by polling, its end is sent as usual
+			if event.EndTime == 0 {
+				redis.AddInProgress(RunningKey(instance), event.ID, time.Duration(conf.RedisTTL)*time.Second)
+			}
+			if redis.ExistsEvent(EventKey(event)) {
+				continue
+			}
+			camera := event.Camera
+			if instance.Name != "" {
+				camera = instance.Name + " " + camera
+			}
+			if counts[camera] == nil {
+				counts[camera] = map[string]int{}
+			}
+			counts[camera][event.Label]++
+			total++
+			if event.EndTime != 0 {
+				redis.AddNewEvent(EventKey(event), "Finished", time.Duration(conf.RedisTTL)*time.Second)
+			}
+			redis.AddNewEvent("WatchDog_"+EventKey(event), "Finished", time.Duration(conf.RedisTTL)*time.Second)
+			RecordHistory(event, history.StatusDigest, "downtime "+gap.String(), nil)
+
+			if len(thumbnails) < DigestThumbnailsLimit {
+				data, _, err := GetEventThumbnail(event)
+				if err != nil {
+					log.Warn.Println("Error getting thumbnail for digest: " + err.Error())
+					continue
+				}
+				img, err := imaging.Decode(data)
+				if err != nil {
+					log.Warn.Println("Error decoding thumbnail for digest: " + err.Error())
+					continue
+				}
+				thumbnails = append(thumbnails, imaging.Tile{
+					Image: img,
+					Label: camera + " " + i18n.LocalTime(time.Unix(int64(event.StartTime), 0)).Format("15:04"),
+				})
+			}
+		}
+		// Events of the gap are in digest, polling continues from now
+		redis.SetEventsCursor(CursorKey(instance, false), float64(now))
+		redis.SetEventsCursor(CursorKey(instance, true), float64(now))
+	}
+
+	lang := i18n.Language(conf.TelegramChatID)
+	text := "<b>" + i18n.T(lang, "bot_down") + "</b> " + markup.Code(i18n.FormatDuration(lang, gap)) + "\n"
+	text += "┣<b>" + i18n.T(lang, "since") + "</b>\n┗ " + markup.Code(i18n.FormatTime(time.Unix(last, 0))) + "\n"
+	if total == 0 {
+		text += "<b>" + i18n.T(lang, "no_events_missed") + "</b>"
+	} else {
+		text += "<b>" + i18n.T(lang, "missed_events", total) + "</b>\n"
+		text += FormatEventCounts(counts)
+	}
+
+	var msg tgbotapi.Chattable
+	// Caption of photo is limited, long digest is sent as text
+	if len(thumbnails) != 0 && len([]rune(text)) <= 1024 {
+		data, err := imaging.EncodeJPEG(imaging.Grid(thumbnails, 4, 240, 240))
+		if err != nil {
+			log.Error.Println("Error encoding digest collage: " + err.Error())
+		} else {
+			photo := tgbotapi.NewPhoto(conf.TelegramChatID, tgbotapi.FileBytes{Name: "digest.jpg", Bytes: data})
+			photo.Caption = text
+			photo.ParseMode = markup.ParseMode
+			photo.DisableNotification = redis.GetStateMuteEvent()
+			msg = photo
+		}
+	}
+	if msg == nil {
+		message := tgbotapi.NewMessage(conf.TelegramChatID, text)
+		message.ParseMode = markup.ParseMode
+		message.DisableNotification = redis.GetStateMuteEvent()
+		msg = message
+	}
+	if _, err := markup.Send(bot, msg); err != nil {
+		log.Error.Println("Error sending downtime digest: " + err.Error())
+	}
+}
@@ -0,0 +1,23 @@
+package frigate
+
+import "testing"
+
+func TestDigestWindow(t *testing.T) {
+	heartbeat, now := int64(10000), int64(20000)
+	tests := []struct {
+		name      string
+		cursor    float64
+		hasCursor bool
+		from      float64
+	}{
+		// Events between cursor and heartbeat were not sent yet by main loop
+		{"saved cursor", 9750.5, true, 9750.5},
+		{"no cursor", 0, false, 9700},
+	}
+	for _, test := range tests {
+		from, to := digestWindow(heartbeat, test.cursor, test.hasCursor, 300, now)
+		if from != test.from || to != float64(now) {
+			t.Errorf("%s: got window %v - %v, want %v - %v", test.name, from, to, test.from, float64(now))
+		}
+	}
+}
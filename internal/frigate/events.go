@@ -0,0 +1,123 @@
+package frigate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path/filepath"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+// Telegram don't send large file see for more: https://github.com/OldTyT/frigate-telegram/issues/5
+const TelegramMaxFileSize = 52428800
+
+// Local Bot API server accepts files up to 2000 MB
+const TelegramLocalMaxFileSize = 2000 * 1024 * 1024
+
+// MaxFileSize returns upload limit of configured Bot API server
+func MaxFileSize() int64 {
+	if config.New().TelegramAPILocal {
+		return TelegramLocalMaxFileSize
+	}
+	return TelegramMaxFileSize
+}
+
+// MediaFile returns file for upload. Local Bot API server reads file by
+// file:// URL itself, so file isn't uploaded via HTTP.
+func MediaFile(FilePath string) tgbotapi.RequestFileData {
+	if !config.New().TelegramAPILocal {
+		return tgbotapi.FilePath(FilePath)
+	}
+	if abs, err := filepath.Abs(FilePath); err == nil {
+		FilePath = abs
+	}
+	return tgbotapi.FileURL("file://" + FilePath)
+}
+
+// QueryEvents returns events of Frigate instance by API params, e.g. camera,
+// label, limit and before.
+func QueryEvents(Instance config.FrigateInstance, Params url.Values) (EventsStruct, error) {
+	var events EventsStruct
+	data, err := GetFrigateBytes(Instance, Instance.URL+"/api/events?"+Params.Encode())
+	if err != nil {
+		return events, err
+	}
+	if err := json.Unmarshal(data, &events); err != nil {
+		return events, err
+	}
+	for i := range events {
+		events[i].Instance = Instance.Name
+	}
+	return events, nil
+}
+
+// GetEvent returns single event of Frigate instance
+func GetEvent(Instance config.FrigateInstance, EventID string) (EventStruct, error) {
+	var event EventStruct
+	data, err := GetFrigateBytes(Instance, Instance.URL+"/api/events/"+url.PathEscape(EventID))
+	if err != nil {
+		return event, err
+	}
+	err = json.Unmarshal(data, &event)
+	event.Instance = Instance.Name
+	return event, err
+}
+
+// GetEventSnapshot returns snapshot of event, thumbnail if event has no snapshot.
+// Params are bbox, crop, timestamp, quality and height of Frigate API.
+// Privacy regions are blurred, cropped snapshot of camera with privacy
+// regions is refused.
+func GetEventSnapshot(FrigateEvent EventStruct, Params url.Values) ([]byte, error) {
+	if !FrigateEvent.HasSnapshot {
+		data, _, err := GetEventThumbnail(FrigateEvent)
+		return data, err
+	}
+	instance := GetInstance(FrigateEvent.Instance)
+	URL := instance.URL + "/api/events/" + FrigateEvent.ID + "/snapshot.jpg"
+	if len(Params) != 0 {
+		URL += "?" + Params.Encode()
+	}
+	data, err := GetFrigateBytes(instance, URL)
+	if err != nil {
+		return nil, err
+	}
+	return BlurPrivacy(FrigateEvent.Instance, FrigateEvent.Camera, data, Params.Get("crop") != "1")
+}
+
+// PrivateClipNote returns link to clip of camera with privacy regions for
+// message, empty for other cameras. Video can't be blurred, so such clip
+// isn't uploaded.
+func PrivateClipNote(Instance string, Camera string, URL string) string {
+	if len(PrivacyRegions(Instance, Camera)) == 0 {
+		return ""
+	}
+	lang := i18n.Language(config.New().TelegramChatID)
+	return i18n.T(lang, "clip_private") + ", " + markup.Link(i18n.T(lang, "download"), URL)
+}
+
+// GetEventPreview returns animated preview of event, it is available while
+// event is in progress. Preview can't be blurred, so camera with privacy
+// regions has no preview.
+func GetEventPreview(FrigateEvent EventStruct) ([]byte, error) {
+	if len(PrivacyRegions(FrigateEvent.Instance, FrigateEvent.Camera)) != 0 {
+		return nil, errors.New("preview of camera with privacy regions can't be blurred")
+	}
+	instance := GetInstance(FrigateEvent.Instance)
+	data, err := GetFrigateBytes(instance, instance.URL+"/api/events/"+FrigateEvent.ID+"/preview.gif")
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) >= MaxFileSize() {
+		return nil, errors.New("preview is larger than Telegram limit")
+	}
+	return data, nil
+}
+
+// ClipURL returns external URL of event clip
+func ClipURL(FrigateEvent EventStruct) string {
+	return GetInstance(FrigateEvent.Instance).ExternalURL + "/api/events/" + FrigateEvent.ID + "/clip.mp4"
+}
@@ -1,49 +1,26 @@
 package frigate
 
 import (
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/history"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
 	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
 	"github.com/oldtyt/frigate-telegram/internal/redis"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-type EventsStruct []struct {
-	Box    interface{} `json:"box"`
-	Camera string      `json:"camera"`
-	Data   struct {
-		Attributes []interface{} `json:"attributes"`
-		Box        []float64     `json:"box"`
-		Region     []float64     `json:"region"`
-		Score      float64       `json:"score"`
-		TopScore   float64       `json:"top_score"`
-		Type       string        `json:"type"`
-	} `json:"data"`
-	EndTime            float64     `json:"end_time"`
-	FalsePositive      interface{} `json:"false_positive"`
-	HasClip            bool        `json:"has_clip"`
-	HasSnapshot        bool        `json:"has_snapshot"`
-	ID                 string      `json:"id"`
-	Label              string      `json:"label"`
-	PlusID             interface{} `json:"plus_id"`
-	RetainIndefinitely bool        `json:"retain_indefinitely"`
-	StartTime          float64     `json:"start_time"`
-	// SubLabel           []any       `json:"sub_label"`
-	Thumbnail string      `json:"thumbnail"`
-	TopScore  interface{} `json:"top_score"`
-	Zones     []any       `json:"zones"`
-}
+type EventsStruct []EventStruct
 
 type EventStruct struct {
 	Box    interface{} `json:"box"`
@@ -65,10 +42,15 @@ type EventStruct struct {
 	PlusID             interface{} `json:"plus_id"`
 	RetainIndefinitely bool        `json:"retain_indefinitely"`
 	StartTime          float64     `json:"start_time"`
-	// SubLabel           []any       `json:"sub_label"`
-	Thumbnail string      `json:"thumbnail"`
-	TopScore  interface{} `json:"top_score"`
-	Zones     []any       `json:"zones"`
+	// String or [name, score] depending on Frigate version, see SubLabelText
+	SubLabel     interface{} `json:"sub_label"`
+	Thumbnail    string      `json:"thumbnail"`
+	TopScore     interface{} `json:"top_score"`
+	Zones        []any       `json:"zones"`
+	CurrentZones []any       `json:"current_zones"`
+	EnteredZones []any       `json:"entered_zones"`
+	// Name of Frigate instance, filled by bot
+	Instance string `json:"instance,omitempty"`
 }
 
 var Events EventsStruct
@@ -100,16 +82,6 @@ func GetTagList(Tags []any) []string {
 	return my_tags
 }
 
-func ErrorSend(TextError string, bot *tgbotapi.BotAPI, EventID string) {
-	conf := config.New()
-	TextError += "\nEventID: " + EventID
-	_, err := bot.Send(tgbotapi.NewMessage(conf.TelegramChatID, TextError))
-	if err != nil {
-		log.Error.Println(err.Error())
-	}
-	log.Error.Fatalln(TextError)
-}
-
 func WarnSend(TextError string, bot *tgbotapi.BotAPI, EventID string) {
 	conf := config.New()
 	TextError += "\nEventID: " + EventID
@@ -120,441 +92,225 @@ func WarnSend(TextError string, bot *tgbotapi.BotAPI, EventID string) {
 	log.Warn.Println(TextError)
 }
 
-func SaveThumbnail(EventID string, Thumbnail string, bot *tgbotapi.BotAPI) string {
-	log.Debug.Printf("Processing thumbnail for event ID: %s", EventID)
-
-	// Verify that we have a non-empty thumbnail string
-	if Thumbnail == "" {
-		ErrorSend("Empty thumbnail string received", bot, EventID)
-	}
-
-	// Decode string Thumbnail base64
-	dec, err := base64.StdEncoding.DecodeString(Thumbnail)
-	if err != nil {
-		ErrorSend("Error when base64 string decode: "+err.Error(), bot, EventID)
-	}
-
-	// Check if we got any data after decoding
-	if len(dec) == 0 {
-		ErrorSend("Decoded thumbnail is empty", bot, EventID)
-	}
-
-	log.Debug.Printf("Decoded thumbnail size: %d bytes", len(dec))
-
-	// Generate uniq filename
-	filename := "/tmp/" + EventID + ".jpg"
-	f, err := os.Create(filename)
-	if err != nil {
-		ErrorSend("Error when create file: "+err.Error(), bot, EventID)
-	}
-	defer f.Close()
-
-	// Write data to file
-	bytesWritten, err := f.Write(dec)
-	if err != nil {
-		ErrorSend("Error when write file: "+err.Error(), bot, EventID)
-	}
-
-	// Check if we wrote anything
-	if bytesWritten == 0 {
-		ErrorSend("No data written to thumbnail file", bot, EventID)
-	}
-
-	log.Debug.Printf("Written %d bytes to %s", bytesWritten, filename)
-
-	// Ensure file is properly synced to disk
-	err = f.Sync()
-	if err != nil {
-		ErrorSend("Error when sync file: "+err.Error(), bot, EventID)
-	}
-
-	// Verify file exists and has content
-	fileInfo, err := os.Stat(filename)
-	if err != nil {
-		ErrorSend("Error verifying thumbnail file: "+err.Error(), bot, EventID)
-	}
-
-	if fileInfo.Size() == 0 {
-		ErrorSend("Thumbnail file is empty after write", bot, EventID)
-	}
-
-	log.Debug.Printf("Successfully saved thumbnail to %s (size: %d bytes)", filename, fileInfo.Size())
-	return filename
-}
-
-func DownloadThumbnail(EventID string, bot *tgbotapi.BotAPI) string {
-	// Get config
+// GetEvents returns one page of events started between After and Before,
+// newest first. Zero After or Before is not sent. Without Thumbnails events
+// have no base64 thumbnail, it is downloaded on demand.
+func GetEvents(Instance config.FrigateInstance, After float64, Before float64, Thumbnails bool) (EventsStruct, error) {
 	conf := config.New()
 
-	// Generate thumbnail URL
-	ThumbnailURL := conf.FrigateURL + "/api/events/" + EventID + "/thumbnail.jpg"
-	log.Debug.Println("Downloading thumbnail from URL: " + ThumbnailURL)
+	FrigateURL := Instance.URL + "/api/events?limit=" + strconv.Itoa(conf.FrigateEventLimit)
 
-	// Generate uniq filename
-	filename := "/tmp/" + EventID + ".jpg"
-
-	// Download thumbnail file
-	resp, err := http.Get(ThumbnailURL)
-	if err != nil {
-		ErrorSend("Error thumbnail download: "+err.Error(), bot, EventID)
-	}
-	defer resp.Body.Close()
-
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		ErrorSend("Return bad status: "+resp.Status, bot, EventID)
+	if After != 0 {
+		FrigateURL = FrigateURL + "&after=" + strconv.FormatFloat(After, 'f', -1, 64)
 	}
-
-	// Read content length if available
-	contentLength := resp.ContentLength
-	if contentLength == 0 {
-		ErrorSend("Received empty thumbnail from server (content length is 0)", bot, EventID)
+	if Before != 0 {
+		FrigateURL = FrigateURL + "&before=" + strconv.FormatFloat(Before, 'f', -1, 64)
 	}
-	log.Debug.Printf("Expected thumbnail content length: %d bytes", contentLength)
-
-	// Create thumbnail file
-	f, err := os.Create(filename)
-	if err != nil {
-		ErrorSend("Error when create file: "+err.Error(), bot, EventID)
-	}
-	defer f.Close() // Ensure file is closed even if there's an error
-
-	// Write the body to file
-	bytesWritten, err := io.Copy(f, resp.Body)
-	if err != nil {
-		ErrorSend("Error thumbnail write: "+err.Error(), bot, EventID)
-	}
-	log.Debug.Printf("Written %d bytes to %s", bytesWritten, filename)
-
-	// Check if we wrote anything
-	if bytesWritten == 0 {
-		ErrorSend("No data written to thumbnail file", bot, EventID)
-	}
-
-	// Ensure file is properly synced to disk
-	err = f.Sync()
-	if err != nil {
-		ErrorSend("Error syncing file to disk: "+err.Error(), bot, EventID)
-	}
-
-	// Verify file exists and has content
-	fileInfo, err := os.Stat(filename)
-	if err != nil {
-		ErrorSend("Error verifying thumbnail file: "+err.Error(), bot, EventID)
-	}
-
-	if fileInfo.Size() == 0 {
-		ErrorSend("Thumbnail file is empty after download", bot, EventID)
-	}
-
-	log.Debug.Printf("Successfully downloaded thumbnail to %s (size: %d bytes)", filename, fileInfo.Size())
-	return filename
-}
-
-func GetEvents(FrigateURL string, bot *tgbotapi.BotAPI, SetBefore bool) EventsStruct {
-	conf := config.New()
-
-	FrigateURL = FrigateURL + "?limit=" + strconv.Itoa(conf.FrigateEventLimit)
-
-	if SetBefore {
-		timestamp := time.Now().UTC().Unix()
-		timestamp = timestamp - int64(conf.EventBeforeSeconds)
-		FrigateURL = FrigateURL + "&before=" + strconv.FormatInt(timestamp, 10)
+	if !Thumbnails {
+		FrigateURL = FrigateURL + "&include_thumbnails=0"
 	}
 
 	log.Debug.Println("Geting events from Frigate via URL: " + FrigateURL)
 
 	// Request to Frigate
-	resp, err := http.Get(FrigateURL)
+	// Unavailable Frigate is reported by health monitor, events are retried on next loop
+	resp, err := FrigateGet(Instance, FrigateURL)
 	if err != nil {
-		ErrorSend("Error get events from Frigate, error: "+err.Error(), bot, "ALL")
+		return nil, fmt.Errorf("error get events from Frigate %s: %w", Instance.Name, err)
 	}
 	defer resp.Body.Close()
 
 	// Check response status code
 	if resp.StatusCode != 200 {
-		WarnSend("Response status != 200, when getting events from Frigate.", bot, "ALL")
-		return EventsStruct{}
+		return nil, fmt.Errorf("response status != 200, when getting events from Frigate %s: %s", Instance.Name, resp.Status)
 	}
 
 	// Read data from response
 	byteValue, err := io.ReadAll(resp.Body)
 	if err != nil {
-		ErrorSend("Can't read JSON: "+err.Error(), bot, "ALL")
+		return nil, fmt.Errorf("can't read events from Frigate %s: %w", Instance.Name, err)
 	}
 
 	// Parse data from JSON to struct
-	err1 := json.Unmarshal(byteValue, &Events)
-	if err1 != nil {
-		ErrorSend("Error unmarshal json: "+err1.Error(), bot, "ALL")
+	var Events EventsStruct
+	if err := json.Unmarshal(byteValue, &Events); err != nil {
 		if e, ok := err.(*json.SyntaxError); ok {
-			log.Info.Println("syntax error at byte offset " + strconv.Itoa(int(e.Offset)))
+			log.Debug.Println("syntax error at byte offset " + strconv.Itoa(int(e.Offset)))
 		}
-		log.Info.Println("Exit.")
+		return nil, fmt.Errorf("error unmarshal events of Frigate %s: %w", Instance.Name, err)
+	}
+
+	for i := range Events {
+		Events[i].Instance = Instance.Name
 	}
 
 	// Return Events
-	return Events
+	return Events, nil
 }
 
-func SaveClip(EventID string, bot *tgbotapi.BotAPI) string {
+func SendMessageEvent(FrigateEvent EventStruct, bot *tgbotapi.BotAPI) {
 	// Get config
 	conf := config.New()
 
-	// Generate clip URL
-	ClipURL := conf.FrigateURL + "/api/events/" + EventID + "/clip.mp4"
-	log.Debug.Println("Downloading clip from URL: " + ClipURL)
-
-	// Generate uniq filename
-	filename := "/tmp/" + EventID + ".mp4"
-
-	// Download clip file
-	resp, err := http.Get(ClipURL)
-	if err != nil {
-		ErrorSend("Error clip download: "+err.Error(), bot, EventID)
-	}
-	defer resp.Body.Close()
-
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		ErrorSend("Return bad status: "+resp.Status, bot, EventID)
-	}
-
-	// Read content length if available
-	contentLength := resp.ContentLength
-	if contentLength == 0 {
-		WarnSend("Received empty clip from server (content length is 0)", bot, EventID)
-		return ""
-	}
-	log.Debug.Printf("Expected content length: %d bytes", contentLength)
-
-	// Create clip file
-	f, err := os.Create(filename)
-	if err != nil {
-		ErrorSend("Error when create file: "+err.Error(), bot, EventID)
-	}
-	defer f.Close() // Ensure file is closed even if there's an error
-
-	// Writer the body to file
-	bytesWritten, err := io.Copy(f, resp.Body)
-	if err != nil {
-		ErrorSend("Error clip write: "+err.Error(), bot, EventID)
-	}
-	log.Debug.Printf("Written %d bytes to %s", bytesWritten, filename)
+	// Must be called before event is marked as processed
+	replyTo, path, incident := CorrelateEvent(FrigateEvent)
 
-	// Check if we wrote anything
-	if bytesWritten == 0 {
-		WarnSend("No data written to clip file", bot, EventID)
-		return ""
-	}
-
-	// Ensure file is properly synced to disk
-	err = f.Sync()
-	if err != nil {
-		ErrorSend("Error syncing file to disk: "+err.Error(), bot, EventID)
-	}
+	redis.AddNewEvent(EventKey(FrigateEvent), "InWork", time.Duration(60)*time.Second)
 
-	// Close the file
-	err = f.Close()
-	if err != nil {
-		ErrorSend("Error closing clip file: "+err.Error(), bot, EventID)
+	// Prepare text message
+	format := TemplateFull
+	if conf.ShortEventMessageFormat {
+		format = TemplateShort
 	}
+	text := RenderEventMessage(FrigateEvent, path, format)
 
-	// Verify file exists and has content
-	fileInfo, err := os.Stat(filename)
-	if err != nil {
-		ErrorSend("Error verifying clip file: "+err.Error(), bot, EventID)
+	// Event in progress is sent with animated preview instead of still image
+	var preview []byte
+	if conf.EventPreview && FrigateEvent.EndTime == 0 {
+		var err error
+		preview, err = GetEventPreview(FrigateEvent)
+		if err != nil {
+			log.Warn.Println("Error getting preview of event " + FrigateEvent.ID + ": " + err.Error())
+		}
 	}
 
-	if fileInfo.Size() == 0 {
-		ErrorSend("Clip file is empty after download", bot, EventID)
+	// Image is sent as usual if preview can't be sent
+	previewSent := false
+	if preview != nil {
+		animation := tgbotapi.NewAnimation(conf.TelegramChatID, tgbotapi.FileBytes{Name: EventKey(FrigateEvent) + ".gif", Bytes: preview})
+		animation.Caption = text
+		animation.ParseMode = markup.ParseMode
+		animation.ReplyToMessageID = replyTo
+		animation.DisableNotification = redis.GetStateMuteEvent()
+		message, err := markup.Send(bot, animation)
+		if err != nil {
+			log.Warn.Println("Error sending preview of event " + FrigateEvent.ID + ", sending image: " + err.Error())
+		} else {
+			previewSent = true
+			SaveCooldownMessage(FrigateEvent, message.MessageID, CooldownMessageCaption, text, bot)
+			SaveIncident(FrigateEvent, message.MessageID, path)
+			RecordHistory(FrigateEvent, history.StatusSent, "", sentMessages(conf.TelegramChatID, []int{message.MessageID}, []string{history.MediaPreview}, false))
+		}
 	}
 
-	log.Debug.Printf("Successfully downloaded clip to %s (size: %d bytes)", filename, fileInfo.Size())
-	return filename
-}
-
-func SendMessageEvent(FrigateEvent EventStruct, bot *tgbotapi.BotAPI) {
-	// Get config
-	conf := config.New()
-
-	redis.AddNewEvent(FrigateEvent.ID, "InWork", time.Duration(60)*time.Second)
-
-	// Prepare text message
-	text := ""
-	t_start := time.Unix(int64(FrigateEvent.StartTime), 0)
-	if conf.ShortEventMessageFormat {
-		// Short message format
-		text += fmt.Sprintf("#%s detected on #%s at %s",
-			NormalizeTagText(FrigateEvent.Label),
-			NormalizeTagText(FrigateEvent.Camera),
-			t_start)
+	var medias []interface{}
 
-	} else {
-		// Normal message format
-		text += "*Event*\n"
-		text += "┣*Camera*\n┗ #" + NormalizeTagText(FrigateEvent.Camera) + "\n"
-		text += "┣*Label*\n┗ #" + NormalizeTagText(FrigateEvent.Label) + "\n"
-		// if FrigateEvent.SubLabel != nil {
-		// 	text += "┣*SubLabel*\n┗ #" + strings.Join(GetTagList(FrigateEvent.SubLabel), ", #") + "\n"
-		// }
-		text += fmt.Sprintf("┣*Start time*\n┗ `%s", t_start) + "`\n"
-		if FrigateEvent.EndTime == 0 {
-			text += "┣*End time*\n┗ `In progess`" + "\n"
+	if conf.IncludeThumbnailEvent && !previewSent {
+		thumbnail, fullFrame, err := GetEventImage(FrigateEvent)
+		if err != nil {
+			log.Error.Println("Error getting thumbnail of event " + FrigateEvent.ID + ": " + err.Error())
 		} else {
-			t_end := time.Unix(int64(FrigateEvent.EndTime), 0)
-			text += fmt.Sprintf("┣*End time*\n┗ `%s", t_end) + "`\n"
+			thumbnail = AnnotateEventImage(FrigateEvent, thumbnail, fullFrame)
+			if conf.IncidentCollage && len(incident) > 1 {
+				thumbnail = IncidentCollage(FrigateEvent, thumbnail, incident)
+			}
+			MediaThumbnail := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: EventKey(FrigateEvent) + ".jpg", Bytes: thumbnail})
+			MediaThumbnail.Caption = text
+			MediaThumbnail.ParseMode = markup.ParseMode
+			medias = append(medias, MediaThumbnail)
 		}
-		text += fmt.Sprintf("┣*Top score*\n┗ `%f", (FrigateEvent.Data.TopScore*100)) + "%`\n"
-		text += "┣*Event id*\n┗ `" + FrigateEvent.ID + "`\n"
-		text += "┣*Zones*\n┗ #" + strings.Join(GetTagList(FrigateEvent.Zones), ", #") + "\n"
-		text += "*URLs*\n"
-		text += "┣[Events](" + conf.FrigateExternalURL + "/events?cameras=" + FrigateEvent.Camera + "&labels=" + FrigateEvent.Label + "&zones=" + strings.Join(GetTagList(FrigateEvent.Zones), ",") + ")\n"
-		text += "┣[General](" + conf.FrigateExternalURL + ")\n"
-		text += "┗[Source clip](" + conf.FrigateExternalURL + "/api/events/" + FrigateEvent.ID + "/clip.mp4)\n"
 	}
 
-	var medias []interface{}
-	var FilePathThumbnail string
-
-	if conf.IncludeThumbnailEvent {
-		// Save thumbnail
-		if FrigateEvent.Thumbnail != "" {
-			// Try to use the base64 thumbnail first
-			log.Debug.Println("Using base64 thumbnail from event data")
-			FilePathThumbnail = SaveThumbnail(FrigateEvent.ID, FrigateEvent.Thumbnail, bot)
-
-			// Verify thumbnail file has content
-			fileInfo, err := os.Stat(FilePathThumbnail)
-			if err != nil || fileInfo.Size() == 0 {
-				log.Debug.Println("Base64 thumbnail failed, trying direct download")
-				// If base64 method failed, try direct download
-				if err == nil {
-					os.Remove(FilePathThumbnail) // Remove empty file
+	var clipFiles []tgbotapi.RequestFileData
+	clipNote := ""
+	if FrigateEvent.HasClip && FrigateEvent.EndTime != 0 {
+		// Clip of camera with privacy regions can't be blurred, link is sent
+		if note := PrivateClipNote(FrigateEvent.Instance, FrigateEvent.Camera, ClipURL(FrigateEvent)); note != "" {
+			clipNote = note
+		} else if clip, err := OpenEventClip(FrigateEvent); err != nil {
+			log.Error.Println("Error getting clip of event " + FrigateEvent.ID + ": " + err.Error())
+		} else {
+			defer clip.Close()
+			if clip.File != nil {
+				clipFiles = append(clipFiles, clip.File)
+			} else {
+				log.Debug.Printf("Clip file is too large (limit: %d)", MaxFileSize())
+				oversize := FitClip(FrigateEvent, clip.Path)
+				defer oversize.Remove()
+				for _, file := range oversize.Files {
+					clipFiles = append(clipFiles, MediaFile(file))
 				}
-				FilePathThumbnail = DownloadThumbnail(FrigateEvent.ID, bot)
+				clipNote = oversize.Note
 			}
-		} else {
-			// No thumbnail in event data, download directly
-			log.Debug.Println("No thumbnail in event data, downloading directly")
-			FilePathThumbnail = DownloadThumbnail(FrigateEvent.ID, bot)
 		}
-
-		// Verify thumbnail file before adding to media group
-		thumbnailInfo, err := os.Stat(FilePathThumbnail)
-		if err != nil {
-			ErrorSend("Error getting thumbnail file info: "+err.Error(), bot, FrigateEvent.ID)
+	}
+	clipLink := len(clipFiles) == 0 && clipNote != ""
+	if clipNote != "" {
+		if conf.ShortEventMessageFormat {
+			text += "\n" + i18n.T(i18n.Language(conf.TelegramChatID), "clip") + ": " + clipNote
+		} else {
+			text += "<b>" + i18n.T(i18n.Language(conf.TelegramChatID), "clip") + "</b>\n┗ " + clipNote + "\n"
 		}
-
-		if thumbnailInfo.Size() == 0 {
-			log.Error.Printf("Thumbnail file is empty: %s", FilePathThumbnail)
-			ErrorSend("Cannot send empty thumbnail file", bot, FrigateEvent.ID)
+		// Caption is set before clip is received
+		if len(medias) != 0 {
+			MediaThumbnail := medias[0].(tgbotapi.InputMediaPhoto)
+			MediaThumbnail.Caption = text
+			medias[0] = MediaThumbnail
 		}
-
-		MediaThumbnail := tgbotapi.NewInputMediaPhoto(tgbotapi.FilePath(FilePathThumbnail))
-		MediaThumbnail.Caption = text
-		MediaThumbnail.ParseMode = tgbotapi.ModeMarkdown
-
-		medias = append(medias, MediaThumbnail)
 	}
 
-	// Define FilePathClip outside the if block to make it available later
-	var FilePathClip string
-	var hasClip bool
+	for i, clipFile := range clipFiles {
+		// Add clip to media group
+		MediaClip := tgbotapi.NewInputMediaVideo(clipFile)
 
-	if FrigateEvent.HasClip && FrigateEvent.EndTime != 0 {
-		// Save clip
-		FilePathClip = SaveClip(FrigateEvent.ID, bot)
-		hasClip = true
-		if FilePathClip == "" {
-			hasClip = false
+		if len(medias) == 0 && i == 0 {
+			MediaClip.Caption = text
+			MediaClip.ParseMode = markup.ParseMode
 		}
-		if hasClip {
-			videoInfo, err := os.Stat(FilePathClip)
-			if err != nil {
-				ErrorSend("Error receiving information about the clip file: "+err.Error(), bot, FrigateEvent.ID)
-			}
-
-			// Double check file size
-			if videoInfo.Size() == 0 {
-				log.Error.Printf("Clip file is empty: %s", FilePathClip)
-				hasClip = false
-			} else if videoInfo.Size() < 52428800 {
-				// Telegram don't send large file see for more: https://github.com/OldTyT/frigate-telegram/issues/5
-				// Add clip to media group
-				log.Debug.Printf("Adding clip to media group: %s (size: %d bytes)", FilePathClip, videoInfo.Size())
-				MediaClip := tgbotapi.NewInputMediaVideo(tgbotapi.FilePath(FilePathClip))
-
-				if !conf.IncludeThumbnailEvent {
-					MediaClip.Caption = text
-				}
 
-				medias = append(medias, MediaClip)
-			} else {
-				log.Debug.Printf("Clip file size is too large: %d bytes (limit: 52428800)", videoInfo.Size())
-			}
-		}
+		medias = append(medias, MediaClip)
 	}
 
 	log.Debug.Printf("Sending media group with %d items", len(medias))
 
-	if len(medias) != 0 {
+	sent := previewSent
+	if !sent && len(medias) != 0 {
 		// Create message
 		msg := tgbotapi.MediaGroupConfig{
-			ChatID: conf.TelegramChatID,
-			Media:  medias,
+			ChatID:           conf.TelegramChatID,
+			Media:            medias,
+			ReplyToMessageID: replyTo,
 		}
 		msg.DisableNotification = redis.GetStateMuteEvent()
 
-		messages, err := bot.SendMediaGroup(msg)
+		messages, err := markup.SendMediaGroup(bot, msg)
+		if err == nil && len(messages) == 0 {
+			err = errors.New("no received messages")
+		}
 		if err != nil {
-			log.Error.Printf("Failed to send media group: %s", err.Error())
-			if strings.Contains(err.Error(), "file must be non-empty") {
-				// Try to get more information about the files we're trying to send
-				for i, media := range medias {
-					switch m := media.(type) {
-					case tgbotapi.InputMediaPhoto:
-						if filePath, ok := m.Media.(tgbotapi.FilePath); ok {
-							fileInfo, statErr := os.Stat(string(filePath))
-							if statErr != nil {
-								log.Error.Printf("Media item %d: Cannot get file info: %s", i, statErr.Error())
-							} else {
-								log.Error.Printf("Media item %d: Photo file exists, size: %d bytes", i, fileInfo.Size())
-							}
-						}
-					case tgbotapi.InputMediaVideo:
-						if filePath, ok := m.Media.(tgbotapi.FilePath); ok {
-							fileInfo, statErr := os.Stat(string(filePath))
-							if statErr != nil {
-								log.Error.Printf("Media item %d: Cannot get file info: %s", i, statErr.Error())
-							} else {
-								log.Error.Printf("Media item %d: Video file exists, size: %d bytes", i, fileInfo.Size())
-							}
-						}
-					}
+			log.Warn.Println("Error sending media group of event " + FrigateEvent.ID + ", sending text: " + err.Error())
+		} else {
+			sent = true
+			SaveCooldownMessage(FrigateEvent, messages[0].MessageID, CooldownMessageCaption, text, bot)
+			SaveIncident(FrigateEvent, messages[0].MessageID, path)
+			var messageIDs []int
+			var kinds []string
+			for i, message := range messages {
+				messageIDs = append(messageIDs, message.MessageID)
+				kind := history.MediaClip
+				if _, ok := medias[i].(tgbotapi.InputMediaPhoto); ok {
+					kind = history.MediaPhoto
 				}
+				kinds = append(kinds, kind)
 			}
-			ErrorSend("Error send media group message: "+err.Error(), bot, FrigateEvent.ID)
-		}
-
-		if messages == nil {
-			ErrorSend("No received messages", bot, FrigateEvent.ID)
+			RecordHistory(FrigateEvent, history.StatusSent, "", sentMessages(conf.TelegramChatID, messageIDs, kinds, clipLink))
 		}
-	} else {
+	}
+	if !sent {
 		msg := tgbotapi.NewMessage(conf.TelegramChatID, "")
 		msg.Text = text
-		if _, err := bot.Send(msg); err != nil {
-			log.Error.Fatalln("Error sending message: " + err.Error())
+		msg.ParseMode = markup.ParseMode
+		msg.ReplyToMessageID = replyTo
+		msg.DisableNotification = redis.GetStateMuteEvent()
+		message, err := markup.Send(bot, msg)
+		if err != nil {
+			log.Error.Println("Error sending message of event " + FrigateEvent.ID + ": " + err.Error())
+			FailIncident(FrigateEvent, path)
+			return
 		}
-	}
-
-	// Now we can safely remove the files after the media group is sent
-	if hasClip {
-		os.Remove(FilePathClip)
-	}
-
-	if conf.IncludeThumbnailEvent {
-		os.Remove(FilePathThumbnail)
+		SaveCooldownMessage(FrigateEvent, message.MessageID, CooldownMessageText, text, bot)
+		SaveIncident(FrigateEvent, message.MessageID, path)
+		RecordHistory(FrigateEvent, history.StatusSent, "", sentMessages(conf.TelegramChatID, []int{message.MessageID}, []string{history.MediaText}, clipLink))
 	}
 
 	var State string
@@ -562,7 +318,29 @@ func SendMessageEvent(FrigateEvent EventStruct, bot *tgbotapi.BotAPI) {
 	if FrigateEvent.EndTime != 0 {
 		State = "Finished"
 	}
-	redis.AddNewEvent(FrigateEvent.ID, State, time.Duration(conf.RedisTTL)*time.Second)
+	redis.AddNewEvent(EventKey(FrigateEvent), State, time.Duration(conf.RedisTTL)*time.Second)
+}
+
+// sentMessages returns history of sent messages, clip link is in caption of
+// the first message
+func sentMessages(ChatID int64, MessageIDs []int, Kinds []string, ClipLink bool) []history.Message {
+	var messages []history.Message
+	for i, id := range MessageIDs {
+		messages = append(messages, history.Message{Chat: ChatID, ID: id, Kind: Kinds[i]})
+	}
+	if ClipLink && len(MessageIDs) != 0 {
+		messages = append(messages, history.Message{Chat: ChatID, ID: MessageIDs[0], Kind: history.MediaLink})
+	}
+	return messages
+}
+
+// StringsToAny converts strings to slice accepted by GetTagList
+func StringsToAny(MySlice []string) []any {
+	var result []any
+	for _, v := range MySlice {
+		result = append(result, v)
+	}
+	return result
 }
 
 func StringsContains(MyStr string, MySlice []string) bool {
@@ -574,6 +352,59 @@ func StringsContains(MyStr string, MySlice []string) bool {
 	return false
 }
 
+// SkipEventReason checks event by camera, label and zone filters of its
+// Frigate instance. Returns reason of skip or empty string.
+func SkipEventReason(FrigateEvent EventStruct) string {
+	conf := GetInstance(FrigateEvent.Instance)
+	// Skip by camera
+	if !(len(conf.ExcludeCamera) == 1 && conf.ExcludeCamera[0] == "None") {
+		if StringsContains(FrigateEvent.Camera, conf.ExcludeCamera) {
+			return "exclude camera: " + FrigateEvent.Camera
+		}
+	}
+	if !(len(conf.IncludeCamera) == 1 && conf.IncludeCamera[0] == "All") {
+		if !(StringsContains(FrigateEvent.Camera, conf.IncludeCamera)) {
+			return "include camera: " + FrigateEvent.Camera
+		}
+	}
+	// Skip by camera
+
+	// Skip by label
+	if !(len(conf.ExcludeLabel) == 1 && conf.ExcludeLabel[0] == "None") {
+		if StringsContains(FrigateEvent.Label, conf.ExcludeLabel) {
+			return "exclude label: " + FrigateEvent.Label
+		}
+	}
+	if !(len(conf.IncludeLabel) == 1 && conf.IncludeLabel[0] == "All") {
+		if !(StringsContains(FrigateEvent.Label, conf.IncludeLabel)) {
+			return "include label: " + FrigateEvent.Label
+		}
+	}
+	// Skip by label
+
+	// Skip by zone
+	zones := GetTagList(FrigateEvent.Zones)
+	if !(len(conf.ExcludeZone) == 1 && conf.ExcludeZone[0] == "None") {
+		for _, zone := range zones {
+			if StringsContains(zone, conf.ExcludeZone) {
+				return "exclude zone: " + zone
+			}
+		}
+	}
+	if !(len(conf.IncludeZone) == 1 && conf.IncludeZone[0] == "All") {
+		if len(zones) == 0 {
+			return "zero zones"
+		}
+		for _, zone := range zones {
+			if !(StringsContains(zone, conf.IncludeZone)) {
+				return "include zone: " + zone
+			}
+		}
+	}
+	// Skip by zone
+	return ""
+}
+
 func ParseEvents(FrigateEvents EventsStruct, bot *tgbotapi.BotAPI, WatchDog bool) {
 	// Parse events
 	conf := config.New()
@@ -582,73 +413,33 @@ func ParseEvents(FrigateEvents EventsStruct, bot *tgbotapi.BotAPI, WatchDog bool
 		RedisKeyPrefix = "WatchDog_"
 	}
 	for Event := range FrigateEvents {
-		// Skip by camera
-		if !(len(conf.FrigateExcludeCamera) == 1 && conf.FrigateExcludeCamera[0] == "None") {
-			if StringsContains(FrigateEvents[Event].Camera, conf.FrigateExcludeCamera) {
-				log.Debug.Println("Skiping event from exclude camera: " + FrigateEvents[Event].Camera)
-				continue
-			}
-		}
-		if !(len(conf.FrigateIncludeCamera) == 1 && conf.FrigateIncludeCamera[0] == "All") {
-			if !(StringsContains(FrigateEvents[Event].Camera, conf.FrigateIncludeCamera)) {
-				log.Debug.Println("Skiping event from include camera: " + FrigateEvents[Event].Camera)
-				continue
+		if reason := SkipEventReason(FrigateEvents[Event]); reason != "" {
+			log.Debug.Println("Skiping event " + FrigateEvents[Event].ID + " by " + reason)
+			if !WatchDog && !history.Exists(EventKey(FrigateEvents[Event])) {
+				RecordHistory(FrigateEvents[Event], history.StatusSkipped, reason, nil)
 			}
+			continue
 		}
-		// Skip by camera
 
-		// Skip by label
-		if !(len(conf.FrigateExcludeLabel) == 1 && conf.FrigateExcludeLabel[0] == "None") {
-			if StringsContains(FrigateEvents[Event].Label, conf.FrigateExcludeLabel) {
-				log.Debug.Println("Skiping event by exclude label: " + FrigateEvents[Event].Label)
-				continue
-			}
+		// Zones are tracked by main poller only, so alerts aren't duplicated
+		if !WatchDog {
+			TrackEventZones(FrigateEvents[Event], bot)
 		}
-		if !(len(conf.FrigateIncludeLabel) == 1 && conf.FrigateIncludeLabel[0] == "All") {
-			if !(StringsContains(FrigateEvents[Event].Label, conf.FrigateIncludeLabel)) {
-				log.Debug.Println("Skiping event by include label: " + FrigateEvents[Event].Label)
-				continue
-			}
-		}
-		// Skip by label
-
-		// Skip by zone
-		zones := GetTagList(FrigateEvents[Event].Zones)
-		needSkip := false
-		if !(len(conf.FrigateExcludeZone) == 1 && conf.FrigateExcludeZone[0] == "None") {
-			if len(zones) != 0 {
-				for _, zone := range zones {
-					if StringsContains(zone, conf.FrigateExcludeZone) {
-						log.Debug.Println("Skiping event by exclude zone: " + zone)
-						needSkip = true
-					}
-				}
-			}
-		}
-		if needSkip {
-			continue
-		}
-		if !(len(conf.FrigateIncludeZone) == 1 && conf.FrigateIncludeZone[0] == "All") {
-			if len(zones) == 0 {
-				log.Debug.Println("Skipping the event due to zero zones.")
+
+		// Only preview is sent for pending event, once
+		if !WatchDog && Pending(FrigateEvents[Event], time.Now()) {
+			if !conf.EventPreview || redis.ExistsEvent(EventKey(FrigateEvents[Event])) {
 				continue
 			}
-			for _, zone := range zones {
-				if !(StringsContains(zone, conf.FrigateIncludeZone)) {
-					log.Debug.Println("Skiping event by include zone: " + zone)
-					needSkip = true
-				}
-			}
-		}
-		if needSkip {
-			continue
 		}
-		// Skip by zone
 
-		if redis.CheckEvent(RedisKeyPrefix + FrigateEvents[Event].ID) {
+		if redis.CheckEvent(RedisKeyPrefix + EventKey(FrigateEvents[Event])) {
 			if WatchDog {
 				SendTextEvent(FrigateEvents[Event], bot)
 			} else {
+				if CollapseEvent(FrigateEvents[Event], bot) {
+					continue
+				}
 				go SendMessageEvent(FrigateEvents[Event], bot)
 			}
 		}
@@ -657,30 +448,69 @@ func ParseEvents(FrigateEvents EventsStruct, bot *tgbotapi.BotAPI, WatchDog bool
 
 func SendTextEvent(FrigateEvent EventStruct, bot *tgbotapi.BotAPI) {
 	conf := config.New()
-	text := "*New event*\n"
-	text += "┣*Camera*\n┗ `" + FrigateEvent.Camera + "`\n"
-	text += "┣*Label*\n┗ `" + FrigateEvent.Label + "`\n"
-	t_start := time.Unix(int64(FrigateEvent.StartTime), 0)
-	text += fmt.Sprintf("┣*Start time*\n┗ `%s", t_start) + "`\n"
-	text += fmt.Sprintf("┣*Top score*\n┗ `%f", (FrigateEvent.Data.TopScore*100)) + "%`\n"
-	text += "┣*Event id*\n┗ `" + FrigateEvent.ID + "`\n"
-	text += "┣*Zones*\n┗ `" + strings.Join(GetTagList(FrigateEvent.Zones), ", ") + "`\n"
-	text += "┣*Event URL*\n┗ " + conf.FrigateExternalURL + "/events?cameras=" + FrigateEvent.Camera + "&labels=" + FrigateEvent.Label + "&zones=" + strings.Join(GetTagList(FrigateEvent.Zones), ",")
+	text := RenderEventMessage(FrigateEvent, nil, TemplateText)
 	msg := tgbotapi.NewMessage(conf.TelegramChatID, text)
-	msg.ParseMode = tgbotapi.ModeMarkdown
+	msg.ParseMode = markup.ParseMode
 	msg.DisableNotification = redis.GetStateMuteEvent()
-	_, err := bot.Send(msg)
+	message, err := markup.Send(bot, msg)
 	if err != nil {
 		log.Error.Println(err.Error())
+	} else {
+		RecordHistory(FrigateEvent, history.StatusSent, "", []history.Message{{Chat: conf.TelegramChatID, ID: message.MessageID, Kind: history.MediaText}})
+	}
+	redis.AddNewEvent("WatchDog_"+EventKey(FrigateEvent), "Finished", time.Duration(conf.RedisTTL)*time.Second)
+}
+
+// Max delay between retries of failed polling
+const MaxRetryDelay = 5 * time.Minute
+
+// retryDelay returns delay before next poll, it is doubled after every failure
+func retryDelay(SleepTime int, Failures int) time.Duration {
+	delay := time.Duration(SleepTime) * time.Second
+	for i := 0; i < Failures && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxRetryDelay {
+		return MaxRetryDelay
+	}
+	return delay
+}
+
+func NotifyEvents(bot *tgbotapi.BotAPI, Instance config.FrigateInstance) {
+	conf := config.New()
+	failures := 0
+	for {
+		FrigateEvents, err := GetNewEvents(Instance, bot, true)
+		if err != nil {
+			failures++
+			log.Warn.Println(err.Error())
+		} else {
+			failures = 0
+			ParseEvents(FrigateEvents, bot, true)
+		}
+		time.Sleep(retryDelay(conf.WatchDogSleepTime, failures))
 	}
-	redis.AddNewEvent("WatchDog_"+FrigateEvent.ID, "Finished", time.Duration(conf.RedisTTL)*time.Second)
 }
 
-func NotifyEvents(bot *tgbotapi.BotAPI, FrigateEventsURL string) {
+func PollEvents(bot *tgbotapi.BotAPI, Instance config.FrigateInstance) {
 	conf := config.New()
+	failures := 0
 	for {
-		FrigateEvents := GetEvents(FrigateEventsURL, bot, false)
-		ParseEvents(FrigateEvents, bot, true)
-		time.Sleep(time.Duration(conf.WatchDogSleepTime) * time.Second)
+		redis.SetHeartbeat()
+		if redis.GetStateSendEvent() {
+			FrigateEvents, err := GetNewEvents(Instance, bot, false)
+			if err != nil {
+				failures++
+				log.Warn.Println(err.Error())
+			} else {
+				failures = 0
+				ParseEvents(FrigateEvents, bot, false)
+			}
+		} else {
+			log.Debug.Println("Skiping send events.")
+		}
+		delay := retryDelay(conf.SleepTime, failures)
+		time.Sleep(delay)
+		log.Debug.Println("Sleeping for " + delay.String() + ".")
 	}
 }
@@ -0,0 +1,119 @@
+package frigate
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+const healthUnreachable = "unreachable"
+
+// CheckHealth returns current problems of Frigate instance by problem key,
+// problems are described in Lang
+func CheckHealth(Instance config.FrigateInstance, Lang string) map[string]string {
+	conf := config.New()
+	problems := map[string]string{}
+
+	if _, err := GetFrigateBytes(Instance, Instance.URL+"/api/version"); err != nil {
+		problems[healthUnreachable] = i18n.T(Lang, "health_unreachable") + ": " + err.Error()
+		return problems
+	}
+	stats, err := GetFrigateStats(Instance)
+	if err != nil {
+		problems[healthUnreachable] = i18n.T(Lang, "error_frigate_stats") + ": " + err.Error()
+		return problems
+	}
+	frigateConfig, err := GetFrigateConfig(Instance)
+	if err != nil {
+		problems[healthUnreachable] = i18n.T(Lang, "error_frigate_config") + ": " + err.Error()
+		return problems
+	}
+
+	for camera, cameraConfig := range frigateConfig.Cameras {
+		// Disabled cameras don't receive frames by design
+		if !cameraConfig.Enabled {
+			continue
+		}
+		if !CameraOnline(stats.Cameras[camera]) {
+			problems["camera:"+camera] = i18n.T(Lang, "health_no_frames", CameraName(Instance, camera))
+		}
+	}
+	for detector, detectorStats := range stats.Detectors {
+		if detectorStats.InferenceSpeed > float64(conf.HealthInferenceSpeed) {
+			problems["detector:"+detector] = i18n.T(Lang, "health_inference", detector, detectorStats.InferenceSpeed)
+		}
+	}
+	for path, storage := range stats.Service.Storage {
+		// Cache and shared memory are tmpfs, their usage is managed by Frigate
+		if storage.Total == 0 || storage.MountType == "tmpfs" {
+			continue
+		}
+		usage := storage.Used / storage.Total * 100
+		if usage > float64(conf.HealthStorageUsage) {
+			problems["storage:"+path] = i18n.T(Lang, "health_storage", path, usage)
+		}
+	}
+	return problems
+}
+
+// sendHealth sends health message of instance to telegram
+func sendHealth(Instance config.FrigateInstance, Messages []string, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	sort.Strings(Messages)
+	text := "Frigate " + Instance.URL + "\n" + strings.Join(Messages, "\n")
+	if Instance.Name != "" {
+		text = "Frigate " + Instance.Name + " (" + Instance.URL + ")\n" + strings.Join(Messages, "\n")
+	}
+	log.Warn.Println(text)
+	if _, err := bot.Send(tgbotapi.NewMessage(conf.TelegramChatID, text)); err != nil {
+		log.Error.Println("Error sending health message: " + err.Error())
+	}
+}
+
+// MonitorHealth periodically checks Frigate instance, alerts once on every new
+// problem and sends recovery message when problem is gone.
+func MonitorHealth(bot *tgbotapi.BotAPI, Instance config.FrigateInstance) {
+	conf := config.New()
+	if conf.HealthCheckInterval <= 0 {
+		return
+	}
+	log.Info.Println("Starting health monitor of Frigate " + Instance.URL)
+	active := map[string]string{}
+	for {
+		lang := i18n.Language(conf.TelegramChatID)
+		problems := CheckHealth(Instance, lang)
+		if _, ok := problems[healthUnreachable]; ok {
+			// Other problems can't be checked, keep them till Frigate is reachable
+			for key, text := range active {
+				if key != healthUnreachable {
+					problems[key] = text
+				}
+			}
+		}
+
+		var alerts, recoveries []string
+		for key, text := range problems {
+			if _, ok := active[key]; !ok {
+				alerts = append(alerts, "⚠️ "+text)
+			}
+		}
+		for key, text := range active {
+			if _, ok := problems[key]; !ok {
+				recoveries = append(recoveries, "✅ "+i18n.T(lang, "recovered")+": "+text)
+			}
+		}
+		if len(alerts) != 0 {
+			sendHealth(Instance, alerts, bot)
+		}
+		if len(recoveries) != 0 {
+			sendHealth(Instance, recoveries, bot)
+		}
+		active = problems
+		time.Sleep(time.Duration(conf.HealthCheckInterval) * time.Second)
+	}
+}
@@ -0,0 +1,24 @@
+package frigate
+
+import (
+	"github.com/oldtyt/frigate-telegram/internal/history"
+)
+
+// RecordHistory saves processed event to history with sent Messages
+func RecordHistory(FrigateEvent EventStruct, Status string, Reason string, Messages []history.Message) {
+	record := history.Record{
+		Key:       EventKey(FrigateEvent),
+		Instance:  FrigateEvent.Instance,
+		ID:        FrigateEvent.ID,
+		Camera:    FrigateEvent.Camera,
+		Label:     FrigateEvent.Label,
+		Zones:     GetZoneList(FrigateEvent.Zones),
+		Score:     FrigateEvent.Data.TopScore,
+		StartTime: FrigateEvent.StartTime,
+		EndTime:   FrigateEvent.EndTime,
+		Status:    Status,
+		Reason:    Reason,
+		Messages:  Messages,
+	}
+	history.Save(record)
+}
@@ -0,0 +1,55 @@
+package frigate
+
+import (
+	"net/http"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+// GetInstance returns Frigate instance by name, first instance if name is unknown
+func GetInstance(Name string) config.FrigateInstance {
+	conf := config.New()
+	for _, instance := range conf.FrigateInstances {
+		if instance.Name == Name {
+			return instance
+		}
+	}
+	log.Warn.Println("Unknown Frigate instance: " + Name)
+	return conf.FrigateInstances[0]
+}
+
+// FrigateGet makes GET request to Frigate instance with configured auth
+func FrigateGet(Instance config.FrigateInstance, URL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if Instance.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+Instance.Token)
+	} else if Instance.User != "" {
+		req.SetBasicAuth(Instance.User, Instance.Password)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// EventKey returns state key of event, event IDs of different instances can collide
+func EventKey(FrigateEvent EventStruct) string {
+	return InstanceKey(FrigateEvent.Instance, FrigateEvent.ID)
+}
+
+// InstanceTag returns hashtag of instance for messages, empty for unnamed instance
+func InstanceTag(FrigateEvent EventStruct) string {
+	if FrigateEvent.Instance == "" {
+		return ""
+	}
+	return " (#" + NormalizeTagText(FrigateEvent.Instance) + ")"
+}
+
+// InstanceKey prefixes key with instance name, unnamed instance keeps old keys
+func InstanceKey(Instance string, Key string) string {
+	if Instance == "" {
+		return Key
+	}
+	return Instance + "_" + Key
+}
@@ -0,0 +1,138 @@
+package frigate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+// LoiteringZoneEnabled checks LOITERING_ZONES rules.
+// Rule format is camera:zone, camera or zone can be `*`.
+func LoiteringZoneEnabled(Camera string, Zone string) bool {
+	conf := config.New()
+	if len(conf.LoiteringZones) == 1 && conf.LoiteringZones[0] == "None" {
+		return false
+	}
+	for _, rule := range conf.LoiteringZones {
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		if len(parts) != 2 {
+			log.Warn.Println("Wrong loitering zone rule: " + rule)
+			continue
+		}
+		if (parts[0] == "*" || parts[0] == Camera) && (parts[1] == "*" || parts[1] == Zone) {
+			return true
+		}
+	}
+	return false
+}
+
+func LoiteringKey(EventID string, Zone string) string {
+	return "Loitering_" + EventID + "_" + Zone
+}
+
+// ProcessLoitering tracks how long the object stays in configured zones.
+// Alert is sent after LOITERING_THRESHOLD seconds, then reply every
+// LOITERING_REMINDER seconds, and when the object leaves the zone the
+// original message is edited with the total dwell time.
+func ProcessLoitering(FrigateEvent EventStruct, Previous []string, Current []string, Tracked bool, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	lang := i18n.Language(conf.TelegramChatID)
+	now := time.Now()
+	ttl := time.Duration(conf.RedisTTL) * time.Second
+
+	for _, zone := range Current {
+		if !LoiteringZoneEnabled(FrigateEvent.Camera, zone) {
+			continue
+		}
+		key := LoiteringKey(EventKey(FrigateEvent), zone)
+		state := redis.GetEventState(key)
+		if len(state) == 0 {
+			// Object was in zone before we saw the event, count from event start
+			since := now.Unix()
+			if !Tracked || StringsContains(zone, Previous) {
+				since = int64(FrigateEvent.StartTime)
+			}
+			state = map[string]string{"since": strconv.FormatInt(since, 10), "msg": "0", "reminders": "0"}
+			redis.SetEventState(key, state, ttl)
+		}
+
+		since, _ := strconv.ParseInt(state["since"], 10, 64)
+		msgID, _ := strconv.Atoi(state["msg"])
+		reminders, _ := strconv.Atoi(state["reminders"])
+		dwell := now.Sub(time.Unix(since, 0))
+
+		if msgID == 0 {
+			if dwell < time.Duration(conf.LoiteringThreshold)*time.Second {
+				continue
+			}
+			text := i18n.T(lang, "loitering", "#"+NormalizeTagText(FrigateEvent.Label), "#"+NormalizeTagText(zone),
+				"#"+NormalizeTagText(FrigateEvent.Camera)+InstanceTag(FrigateEvent), markup.Code(FormatDwell(lang, dwell))) + "\n"
+			text += "┗<b>" + i18n.T(lang, "event_id") + "</b> " + markup.Code(FrigateEvent.ID)
+			msg := tgbotapi.NewMessage(conf.TelegramChatID, text)
+			msg.ParseMode = markup.ParseMode
+			msg.DisableNotification = redis.GetStateMuteEvent()
+			message, err := markup.Send(bot, msg)
+			if err != nil {
+				log.Error.Println(err.Error())
+				continue
+			}
+			redis.SetEventState(key, map[string]string{"msg": strconv.Itoa(message.MessageID), "text": text}, ttl)
+			continue
+		}
+
+		if conf.LoiteringReminder <= 0 {
+			continue
+		}
+		next := time.Duration(conf.LoiteringThreshold+conf.LoiteringReminder*(reminders+1)) * time.Second
+		if dwell < next {
+			continue
+		}
+		text := i18n.T(lang, "loitering_reminder", "#"+NormalizeTagText(FrigateEvent.Label), "#"+NormalizeTagText(zone),
+			markup.Code(FormatDwell(lang, dwell)))
+		msg := tgbotapi.NewMessage(conf.TelegramChatID, text)
+		msg.ParseMode = markup.ParseMode
+		msg.ReplyToMessageID = msgID
+		msg.DisableNotification = redis.GetStateMuteEvent()
+		if _, err := markup.Send(bot, msg); err != nil {
+			log.Error.Println(err.Error())
+			continue
+		}
+		redis.SetEventState(key, map[string]string{"reminders": strconv.Itoa(reminders + 1)}, ttl)
+	}
+
+	for _, zone := range ZonesDiff(Previous, Current) {
+		key := LoiteringKey(EventKey(FrigateEvent), zone)
+		state := redis.GetEventState(key)
+		if len(state) == 0 {
+			continue
+		}
+		redis.DelEventState(key)
+		msgID, _ := strconv.Atoi(state["msg"])
+		if msgID == 0 {
+			continue
+		}
+		since, _ := strconv.ParseInt(state["since"], 10, 64)
+		left := now
+		if FrigateEvent.EndTime != 0 {
+			left = time.Unix(int64(FrigateEvent.EndTime), 0)
+		}
+		text := state["text"] + "\n<b>" + i18n.T(lang, "left_after") + "</b> " + markup.Code(FormatDwell(lang, left.Sub(time.Unix(since, 0))))
+		edit := tgbotapi.NewEditMessageText(conf.TelegramChatID, msgID, text)
+		edit.ParseMode = markup.ParseMode
+		if _, err := markup.Request(bot, edit); err != nil {
+			log.Error.Println(err.Error())
+		}
+	}
+}
+
+// FormatDwell returns human dwell time for messages
+func FormatDwell(Lang string, Dwell time.Duration) string {
+	return i18n.FormatDuration(Lang, Dwell)
+}
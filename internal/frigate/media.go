@@ -0,0 +1,201 @@
+package frigate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+// ErrNotFound is returned when Frigate has no requested object, e.g. event is deleted
+var ErrNotFound = errors.New("not found in Frigate")
+
+// GetFrigateBytes downloads URL of Frigate instance to memory
+func GetFrigateBytes(Instance config.FrigateInstance, URL string) ([]byte, error) {
+	log.Debug.Println("Downloading from URL: " + URL)
+	resp, err := FrigateGet(Instance, URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("return bad status: " + resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("received empty body")
+	}
+	return data, nil
+}
+
+// GetThumbnailBytes returns thumbnail from event data or downloads it from Frigate
+func GetThumbnailBytes(FrigateEvent EventStruct) ([]byte, error) {
+	if FrigateEvent.Thumbnail != "" {
+		dec, err := base64.StdEncoding.DecodeString(FrigateEvent.Thumbnail)
+		if err == nil && len(dec) != 0 {
+			return dec, nil
+		}
+	}
+	instance := GetInstance(FrigateEvent.Instance)
+	return GetFrigateBytes(instance, instance.URL+"/api/events/"+FrigateEvent.ID+"/thumbnail.jpg")
+}
+
+// EventClip is clip of event or recording prepared for upload. Clip fitting Telegram
+// limit is streamed from Frigate response, otherwise it is spooled to
+// MEDIA_CACHE_DIR and File is empty.
+type EventClip struct {
+	File tgbotapi.RequestFileData
+	// Spooled file, empty if clip is streamed
+	Path string
+	body io.Closer
+}
+
+// Close closes Frigate response and removes spooled file
+func (c EventClip) Close() {
+	if c.body != nil {
+		c.body.Close()
+	}
+	if c.Path != "" {
+		os.Remove(c.Path)
+	}
+}
+
+// limitedReader fails upload when stream is larger than expected
+type limitedReader struct {
+	reader io.Reader
+	left   int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.left -= int64(n)
+	if r.left < 0 {
+		return n, errors.New("stream is larger than Telegram limit")
+	}
+	return n, err
+}
+
+// CleanMediaCache creates MEDIA_CACHE_DIR and removes media files left by
+// previous run. Only media files are removed, directory can be shared.
+func CleanMediaCache() {
+	conf := config.New()
+	if err := os.MkdirAll(conf.MediaCacheDir, 0o700); err != nil {
+		log.Error.Println("Error creating media cache: " + err.Error())
+		return
+	}
+	for _, pattern := range []string{"*.mp4", "*.jpg", "*.gif"} {
+		files, _ := filepath.Glob(filepath.Join(conf.MediaCacheDir, pattern))
+		for _, file := range files {
+			if err := os.Remove(file); err != nil {
+				log.Warn.Println("Error cleaning media cache: " + err.Error())
+			}
+		}
+	}
+}
+
+// spoolFile writes Reader to MEDIA_CACHE_DIR
+func spoolFile(Name string, Reader io.Reader) (string, int64, error) {
+	path := filepath.Join(config.New().MediaCacheDir, Name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	size, err := io.Copy(f, Reader)
+	if err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+	return path, size, nil
+}
+
+// OpenFrigateStream requests URL of Frigate instance, caller must close body
+func OpenFrigateStream(Instance config.FrigateInstance, URL string) (*http.Response, error) {
+	log.Debug.Println("Downloading from URL: " + URL)
+	resp, err := FrigateGet(Instance, URL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New("return bad status: " + resp.Status)
+	}
+	if resp.ContentLength == 0 {
+		resp.Body.Close()
+		return nil, errors.New("received empty body")
+	}
+	return resp, nil
+}
+
+// OpenEventClip requests clip of event. Caller must Close the clip after sending.
+func OpenEventClip(FrigateEvent EventStruct) (EventClip, error) {
+	instance := GetInstance(FrigateEvent.Instance)
+	return openClip(instance, instance.URL+"/api/events/"+FrigateEvent.ID+"/clip.mp4",
+		InstanceKey(FrigateEvent.Instance, FrigateEvent.ID)+".mp4")
+}
+
+// OpenRecordingClip requests recording of camera for time range. Caller
+// must Close the clip after sending.
+func OpenRecordingClip(Instance config.FrigateInstance, Camera string, From time.Time, To time.Time) (EventClip, error) {
+	name := InstanceKey(Instance.Name, Camera) + "_" + strconv.FormatInt(From.Unix(), 10) + "_" + strconv.FormatInt(To.Unix(), 10) + ".mp4"
+	return openClip(Instance, RecordingURL(Instance.URL, Camera, From, To), filepath.Base(name))
+}
+
+// openClip requests clip by URL, clip fitting Telegram limit is streamed,
+// larger clip is spooled to MEDIA_CACHE_DIR
+func openClip(Instance config.FrigateInstance, URL string, Name string) (EventClip, error) {
+	var clip EventClip
+	conf := config.New()
+	resp, err := OpenFrigateStream(Instance, URL)
+	if err != nil {
+		return clip, err
+	}
+	limit := MaxFileSize()
+
+	// Local Bot API server reads file itself, so clip is always spooled
+	if !conf.TelegramAPILocal && resp.ContentLength > 0 && resp.ContentLength < limit {
+		clip.File = tgbotapi.FileReader{Name: Name, Reader: &limitedReader{reader: resp.Body, left: resp.ContentLength}}
+		clip.body = resp.Body
+		return clip, nil
+	}
+
+	defer resp.Body.Close()
+	var reader io.Reader = resp.Body
+	if !conf.TelegramAPILocal && resp.ContentLength < 0 {
+		// Unknown size, clip is kept in memory while it fits the limit
+		data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+		if err != nil {
+			return clip, err
+		}
+		if int64(len(data)) < limit {
+			clip.File = tgbotapi.FileBytes{Name: Name, Bytes: data}
+			return clip, nil
+		}
+		reader = io.MultiReader(bytes.NewReader(data), resp.Body)
+	}
+	path, size, err := spoolFile(Name, reader)
+	if err != nil {
+		return clip, err
+	}
+	log.Debug.Printf("Spooled clip to %s (size: %d bytes)", path, size)
+	clip.Path = path
+	if size < limit {
+		clip.File = MediaFile(path)
+	}
+	return clip, nil
+}
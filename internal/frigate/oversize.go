@@ -0,0 +1,243 @@
+package frigate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+const (
+	ClipStrategyTranscode = "transcode"
+	ClipStrategySplit     = "split"
+	ClipStrategyTrim      = "trim"
+	ClipStrategyLink      = "link"
+	ClipStrategySkip      = "skip"
+)
+
+// Media group can't have more than 10 items, one is reserved for thumbnail
+const maxClipParts = 9
+
+var (
+	ffmpegOnce  sync.Once
+	ffmpegFound bool
+)
+
+// FFmpegAvailable checks FFMPEG_PATH once, transcode, split and trim
+// strategies are skipped without ffmpeg
+func FFmpegAvailable() bool {
+	ffmpegOnce.Do(func() {
+		_, err := exec.LookPath(config.New().FFmpegPath)
+		ffmpegFound = err == nil
+	})
+	return ffmpegFound
+}
+
+// CheckFFmpeg warns on startup if CLIP_OVERSIZE_STRATEGY needs ffmpeg which is not found
+func CheckFFmpeg() {
+	conf := config.New()
+	for _, strategy := range conf.ClipOversizeStrategy {
+		if needsFFmpeg(strategy) && !FFmpegAvailable() {
+			log.Warn.Println("ffmpeg not found at FFMPEG_PATH=" + conf.FFmpegPath + ", clip oversize strategies transcode, split and trim are disabled, large clips are sent as link")
+			return
+		}
+	}
+}
+
+func needsFFmpeg(Strategy string) bool {
+	return Strategy == ClipStrategyTranscode || Strategy == ClipStrategySplit || Strategy == ClipStrategyTrim
+}
+
+var ffmpegDurationRegexp = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+
+// OversizeClip is result of oversize strategy, Files are empty if clip is sent as link
+type OversizeClip struct {
+	Files []string
+	Note  string
+}
+
+// Remove removes prepared files
+func (c OversizeClip) Remove() {
+	removeFiles(c.Files)
+}
+
+// FitClip applies CLIP_OVERSIZE_STRATEGY to clip of event larger than
+// Telegram limit
+func FitClip(FrigateEvent EventStruct, FilePath string) OversizeClip {
+	return FitClipFile(FilePath, ClipURL(FrigateEvent), "event "+FrigateEvent.ID)
+}
+
+// FitClipFile applies CLIP_OVERSIZE_STRATEGY to spooled clip larger than
+// Telegram limit, link strategy sends URL. Strategies are tried in order
+// until one succeeds, link is used if all of them failed.
+func FitClipFile(FilePath string, URL string, Name string) OversizeClip {
+	conf := config.New()
+	lang := i18n.Language(conf.TelegramChatID)
+	link := OversizeClip{Note: i18n.T(lang, "clip_too_large") + ", " + markup.Link(i18n.T(lang, "download"), URL)}
+	for _, strategy := range conf.ClipOversizeStrategy {
+		if needsFFmpeg(strategy) && !FFmpegAvailable() {
+			log.Warn.Println("Clip oversize strategy " + strategy + " skipped for " + Name + ": ffmpeg not found at FFMPEG_PATH=" + conf.FFmpegPath)
+			continue
+		}
+		var files []string
+		var note string
+		var err error
+		switch strategy {
+		case ClipStrategyTranscode:
+			files, err = transcodeClip(FilePath)
+			note = i18n.T(lang, "clip_transcoded")
+		case ClipStrategySplit:
+			files, err = splitClip(FilePath)
+			note = i18n.T(lang, "clip_split", len(files))
+		case ClipStrategyTrim:
+			files, err = trimClip(FilePath)
+			note = i18n.T(lang, "clip_trimmed", i18n.FormatDuration(lang, time.Duration(conf.ClipTrimSeconds)*time.Second))
+		case ClipStrategyLink:
+			return link
+		case ClipStrategySkip:
+			return OversizeClip{}
+		default:
+			err = errors.New("unknown strategy")
+		}
+		if err != nil {
+			log.Warn.Println("Clip oversize strategy " + strategy + " failed for " + Name + ": " + err.Error())
+			removeFiles(files)
+			continue
+		}
+		return OversizeClip{Files: files, Note: note}
+	}
+	log.Warn.Println("All clip oversize strategies failed for " + Name + ", sending link")
+	return link
+}
+
+// clipTargetSize returns size of prepared clip with reserve, container overhead isn't exact
+func clipTargetSize() int64 {
+	return MaxFileSize() * 9 / 10
+}
+
+func removeFiles(Files []string) {
+	for _, file := range Files {
+		os.Remove(file)
+	}
+}
+
+func runFFmpeg(Args ...string) (string, error) {
+	conf := config.New()
+	var stderr bytes.Buffer
+	cmd := exec.Command(conf.FFmpegPath, Args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		log.Debug.Println("ffmpeg output: " + stderr.String())
+	}
+	return stderr.String(), err
+}
+
+// clipDuration returns duration of clip in seconds from ffmpeg output
+func clipDuration(FilePath string) (float64, error) {
+	// ffmpeg without output file exits with error, but prints input info
+	output, _ := runFFmpeg("-hide_banner", "-i", FilePath)
+	match := ffmpegDurationRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return 0, errors.New("can't get clip duration")
+	}
+	hours, _ := strconv.ParseFloat(match[1], 64)
+	minutes, _ := strconv.ParseFloat(match[2], 64)
+	seconds, _ := strconv.ParseFloat(match[3], 64)
+	duration := hours*3600 + minutes*60 + seconds
+	if duration <= 0 {
+		return 0, errors.New("clip duration is 0")
+	}
+	return duration, nil
+}
+
+// checkClipSize returns error if any file doesn't fit Telegram limit
+func checkClipSize(Files []string) error {
+	for _, file := range Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if info.Size() == 0 || info.Size() >= MaxFileSize() {
+			return fmt.Errorf("result size %d bytes doesn't fit Telegram limit", info.Size())
+		}
+	}
+	return nil
+}
+
+func outputPath(FilePath string, Suffix string) string {
+	return FilePath[:len(FilePath)-len(filepath.Ext(FilePath))] + "_" + Suffix + ".mp4"
+}
+
+// transcodeClip reencodes clip with bitrate fitting Telegram limit, video is
+// downscaled to 720p and audio is dropped
+func transcodeClip(FilePath string) ([]string, error) {
+	duration, err := clipDuration(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	bitrate := int(float64(clipTargetSize()*8) / duration / 1000)
+	if bitrate < 100 {
+		return nil, errors.New("clip is too long for transcoding")
+	}
+	output := outputPath(FilePath, "transcoded")
+	_, err = runFFmpeg("-hide_banner", "-y", "-i", FilePath,
+		"-vf", "scale=-2:'min(720,ih)'", "-c:v", "libx264", "-preset", "veryfast",
+		"-b:v", strconv.Itoa(bitrate)+"k", "-maxrate", strconv.Itoa(bitrate)+"k", "-bufsize", strconv.Itoa(bitrate*2)+"k",
+		"-an", "-movflags", "+faststart", output)
+	if err != nil {
+		return []string{output}, err
+	}
+	return []string{output}, checkClipSize([]string{output})
+}
+
+// splitClip splits clip without reencoding into parts fitting Telegram limit
+func splitClip(FilePath string) ([]string, error) {
+	duration, err := clipDuration(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	segment := duration * float64(clipTargetSize()) / float64(info.Size())
+	if int(duration/segment)+1 > maxClipParts {
+		return nil, errors.New("clip needs too many parts")
+	}
+	pattern := outputPath(FilePath, "part%02d")
+	_, err = runFFmpeg("-hide_banner", "-y", "-i", FilePath, "-c", "copy", "-map", "0",
+		"-f", "segment", "-segment_time", strconv.FormatFloat(segment, 'f', 1, 64),
+		"-reset_timestamps", "1", pattern)
+	files, _ := filepath.Glob(outputPath(FilePath, "part*"))
+	if err != nil {
+		return files, err
+	}
+	if len(files) == 0 || len(files) > maxClipParts {
+		return files, fmt.Errorf("clip is split into %d parts", len(files))
+	}
+	return files, checkClipSize(files)
+}
+
+// trimClip cuts first CLIP_TRIM_SECONDS of clip, clip starts shortly before detection
+func trimClip(FilePath string) ([]string, error) {
+	conf := config.New()
+	output := outputPath(FilePath, "trimmed")
+	_, err := runFFmpeg("-hide_banner", "-y", "-i", FilePath, "-t", strconv.Itoa(conf.ClipTrimSeconds),
+		"-c", "copy", "-movflags", "+faststart", output)
+	if err != nil {
+		return []string{output}, err
+	}
+	return []string{output}, checkClipSize([]string{output})
+}
@@ -0,0 +1,123 @@
+package frigate
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+)
+
+var timeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+}
+
+// ParseTime parses time input: `now`, `18:30`, `18:30:15` (today, or
+// yesterday if it is in future), `2024-05-01 18:30`, `10 minutes ago`,
+// `10m ago`, `yesterday 18:30` or unix timestamp.
+func ParseTime(Value string, Now time.Time) (time.Time, error) {
+	value := strings.ToLower(strings.TrimSpace(Value))
+	if value == "now" {
+		return Now, nil
+	}
+	if strings.HasSuffix(value, " ago") {
+		ago, err := ParseDurationText(strings.TrimSuffix(value, " ago"))
+		if err != nil {
+			return Now, err
+		}
+		return Now.Add(-ago), nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil && ts > 100000000 {
+		return time.Unix(ts, 0), nil
+	}
+	day, yesterday := Now, false
+	if rest, ok := strings.CutPrefix(value, "yesterday "); ok {
+		day, yesterday = Now.AddDate(0, 0, -1), true
+		value = rest
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, Now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		t, err := time.ParseInLocation(layout, value, Now.Location())
+		if err != nil {
+			continue
+		}
+		t = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, Now.Location())
+		if t.After(Now) && !yesterday {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+	return Now, i18n.NewError("wrong_time", Value)
+}
+
+// ParseDurationText parses duration like `90s`, `1h30m`, `10 minutes` or `2 h`
+func ParseDurationText(Value string) (time.Duration, error) {
+	value := strings.ToLower(strings.TrimSpace(Value))
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, nil
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 1 {
+		// Number with unit without space, e.g. `2d`
+		i := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+		if i > 0 {
+			fields = []string{value[:i], value[i:]}
+		}
+	}
+	if len(fields) != 2 {
+		return 0, i18n.NewError("wrong_duration", Value)
+	}
+	count, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, i18n.NewError("wrong_duration", Value)
+	}
+	unit, ok := timeUnits[fields[1]]
+	if !ok {
+		return 0, i18n.NewError("unknown_unit", fields[1])
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// ParseClipRange parses start time and duration of recording clip, clip
+// can't be longer than CLIP_MAX_DURATION and is cut at current time.
+func ParseClipRange(From string, Duration string, Now time.Time) (time.Time, time.Time, error) {
+	conf := config.New()
+	from, err := ParseTime(From, Now)
+	if err != nil {
+		return from, from, err
+	}
+	duration, err := ParseDurationText(Duration)
+	if err != nil {
+		return from, from, err
+	}
+	if duration <= 0 {
+		return from, from, i18n.NewError("duration_positive")
+	}
+	if duration > time.Duration(conf.ClipMaxDuration)*time.Second {
+		return from, from, i18n.NewError("duration_too_long", (time.Duration(conf.ClipMaxDuration) * time.Second).String())
+	}
+	to := from.Add(duration)
+	if to.After(Now) {
+		to = Now
+	}
+	if !to.After(from) {
+		return from, to, i18n.NewError("clip_in_future")
+	}
+	return from, to, nil
+}
+
+// RecordingURL returns URL of recording clip of camera for time range
+func RecordingURL(BaseURL string, Camera string, From time.Time, To time.Time) string {
+	return BaseURL + "/api/" + url.PathEscape(Camera) +
+		"/start/" + strconv.FormatInt(From.Unix(), 10) +
+		"/end/" + strconv.FormatInt(To.Unix(), 10) + "/clip.mp4"
+}
@@ -0,0 +1,78 @@
+package frigate
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+// Params of Frigate /api/events/<id>/snapshot.jpg
+var snapshotParams = []string{"bbox", "crop", "timestamp", "quality", "height"}
+
+// SnapshotParams returns params of full snapshot for camera from EVENT_SNAPSHOT
+// rules `camera[:params]`, e.g. `porch:bbox=1&height=720` or `*:crop=1`,
+// camera of named instance can be `instance/camera`. False is returned if
+// thumbnail should be sent.
+func SnapshotParams(Instance string, Camera string) (url.Values, bool) {
+	conf := config.New()
+	if len(conf.EventSnapshot) == 1 && conf.EventSnapshot[0] == "None" {
+		return nil, false
+	}
+	for _, rule := range conf.EventSnapshot {
+		camera, query, _ := strings.Cut(strings.TrimSpace(rule), ":")
+		if !CameraMatches(camera, Instance, Camera) {
+			continue
+		}
+		params, err := url.ParseQuery(query)
+		if err != nil {
+			log.Warn.Println("Wrong event snapshot rule: " + rule)
+			continue
+		}
+		for key := range params {
+			if !StringsContains(key, snapshotParams) {
+				log.Warn.Println("Unknown event snapshot param " + key + " in rule: " + rule)
+				params.Del(key)
+			}
+		}
+		return params, true
+	}
+	return nil, false
+}
+
+// GetEventImage returns photo of event for message: full snapshot if it is
+// enabled for camera and event has snapshot, thumbnail otherwise. Returned
+// bool is true for full frame, i.e. snapshot without crop. Camera with
+// privacy regions always gets full snapshot, see GetEventThumbnail.
+func GetEventImage(FrigateEvent EventStruct) ([]byte, bool, error) {
+	params, ok := SnapshotParams(FrigateEvent.Instance, FrigateEvent.Camera)
+	if ok && FrigateEvent.HasSnapshot {
+		// Box is drawn by annotation, Frigate box would be drawn twice
+		if AnnotateEnabled(FrigateEvent.Instance, FrigateEvent.Camera) && params.Get("bbox") == "1" {
+			params.Set("bbox", "0")
+		}
+		data, err := GetEventSnapshot(FrigateEvent, params)
+		if err == nil {
+			return data, params.Get("crop") != "1", nil
+		}
+		log.Warn.Println("Error getting snapshot of event " + FrigateEvent.ID + ", sending thumbnail: " + err.Error())
+	}
+	return GetEventThumbnail(FrigateEvent)
+}
+
+// GetEventThumbnail returns thumbnail of event. Thumbnail is cropped and
+// can't be blurred, so full snapshot with blurred privacy regions is
+// returned for camera with privacy regions. Returned bool is true for full
+// frame.
+func GetEventThumbnail(FrigateEvent EventStruct) ([]byte, bool, error) {
+	if len(PrivacyRegions(FrigateEvent.Instance, FrigateEvent.Camera)) == 0 {
+		data, err := GetThumbnailBytes(FrigateEvent)
+		return data, false, err
+	}
+	if !FrigateEvent.HasSnapshot {
+		return nil, false, ErrPrivacyCrop
+	}
+	data, err := GetEventSnapshot(FrigateEvent, url.Values{"height": {"360"}})
+	return data, true, err
+}
@@ -0,0 +1,340 @@
+package frigate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+// Built-in message templates
+const (
+	TemplateFull  = "full"
+	TemplateShort = "short"
+	TemplateText  = "text"
+)
+
+// Built-in templates reproduce default message formats, they can be
+// overridden by files with the same name in MESSAGE_TEMPLATE_DIR
+var builtinTemplates = map[string]string{
+	TemplateFull: `<b>{{t "event"}}</b>
+{{if .Instance}}┣<b>Frigate</b>
+┗ #{{tag .Instance}}
+{{end}}┣<b>{{t "camera"}}</b>
+┗ #{{tag .Camera}}
+┣<b>{{t "label"}}</b>
+┗ #{{tag .Label}}
+┣<b>{{t "start_time"}}</b>
+┗ <code>{{datetime .Start}}</code>
+┣<b>{{t "end_time"}}</b>
+┗ <code>{{if .InProgress}}{{t "in_progress"}}{{else}}{{datetime .End}}{{end}}</code>{{if not .InProgress}}, {{t "lasted" (duration .Duration)}}{{end}}
+┣<b>{{t "top_score"}}</b>
+┗ <code>{{printf "%f" .Score}}%</code>
+┣<b>{{t "event_id"}}</b>
+┗ <code>{{.ID}}</code>
+┣<b>{{t "zones"}}</b>
+┗ #{{tags .Zones | join ", #"}}
+{{if gt (len .Path) 1}}┣<b>{{t "path"}}</b>
+┗ #{{tags .Path | join " → #"}}
+{{end}}<b>{{t "urls"}}</b>
+┣<a href="{{.URL.Events}}">{{t "events"}}</a>
+┣<a href="{{.URL.General}}">{{t "general"}}</a>
+┗<a href="{{.URL.Clip}}">{{t "source_clip"}}</a>
+`,
+	TemplateShort: `{{t "detected" (hashtag .Label) (hashtag .Camera) (datetime .Start)}}` +
+		`{{if .Instance}} ({{hashtag .Instance}}){{end}}` +
+		`{{if not .InProgress}}, {{t "lasted" (duration .Duration)}}{{end}}` +
+		`{{if gt (len .Path) 1}}
+{{t "path"}}: {{join " → " .Path}}{{end}}`,
+	TemplateText: `<b>{{t "new_event"}}</b>
+{{if .Instance}}┣<b>Frigate</b>
+┗ <code>{{.Instance}}</code>
+{{end}}┣<b>{{t "camera"}}</b>
+┗ <code>{{.Camera}}</code>
+┣<b>{{t "label"}}</b>
+┗ <code>{{.Label}}</code>
+┣<b>{{t "start_time"}}</b>
+┗ <code>{{datetime .Start}}</code>
+┣<b>{{t "top_score"}}</b>
+┗ <code>{{printf "%f" .Score}}%</code>
+┣<b>{{t "event_id"}}</b>
+┗ <code>{{.ID}}</code>
+┣<b>{{t "zones"}}</b>
+┗ <code>{{tags .Zones | join ", "}}</code>
+┣<b>{{t "event_url"}}</b>
+┗ {{.URL.Events}}`,
+}
+
+// MessageURLs are links of event message
+type MessageURLs struct {
+	// Frigate events page filtered by camera, label and zones
+	Events string
+	// Frigate UI
+	General  string
+	Clip     string
+	Snapshot string
+}
+
+// MessageData is event model available in message templates
+type MessageData struct {
+	ID       string
+	Instance string
+	Camera   string
+	Label    string
+	// Sub label, e.g. recognized face, empty if not set
+	SubLabel string
+	// Top score in percent
+	Score float64
+	Zones []string
+	// Cameras of cross-camera incident, empty for single camera
+	Path  []string
+	Start time.Time
+	// Zero while event is in progress
+	End        time.Time
+	InProgress bool
+	Duration   time.Duration
+	URL        MessageURLs
+	// Language of chat
+	Lang string
+}
+
+// templateFuncs returns functions of message templates, text is translated to Lang
+func templateFuncs(Lang string) template.FuncMap {
+	return template.FuncMap{
+		"tag": NormalizeTagText,
+		"hashtag": func(Text string) string {
+			return "#" + NormalizeTagText(Text)
+		},
+		"tags": func(List []string) []string {
+			tags := make([]string, 0, len(List))
+			for _, item := range List {
+				tags = append(tags, NormalizeTagText(item))
+			}
+			return tags
+		},
+		// Separator is the first argument, so list can be piped
+		"join": func(Sep string, List []string) string {
+			return strings.Join(List, Sep)
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"t": func(Key string, Args ...interface{}) string {
+			return i18n.T(Lang, Key, Args...)
+		},
+		"datetime": i18n.FormatTime,
+		"duration": func(Duration time.Duration) string {
+			return i18n.FormatDuration(Lang, Duration)
+		},
+	}
+}
+
+// SubLabelText returns sub label of event, Frigate sends it as string or
+// as [name, score] pair depending on version
+func SubLabelText(SubLabel interface{}) string {
+	switch value := SubLabel.(type) {
+	case string:
+		return value
+	case []interface{}:
+		if len(value) != 0 {
+			if name, ok := value[0].(string); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// NewMessageData returns template model of event, Path is list of incident
+// cameras, Lang is language of chat
+func NewMessageData(FrigateEvent EventStruct, Path []string, Lang string) MessageData {
+	instance := GetInstance(FrigateEvent.Instance)
+	data := MessageData{
+		Lang:       Lang,
+		ID:         FrigateEvent.ID,
+		Instance:   FrigateEvent.Instance,
+		Camera:     FrigateEvent.Camera,
+		Label:      FrigateEvent.Label,
+		SubLabel:   SubLabelText(FrigateEvent.SubLabel),
+		Score:      FrigateEvent.Data.TopScore * 100,
+		Path:       Path,
+		Start:      time.Unix(int64(FrigateEvent.StartTime), 0),
+		InProgress: FrigateEvent.EndTime == 0,
+		URL: MessageURLs{
+			Events: instance.ExternalURL + "/events?cameras=" + url.QueryEscape(FrigateEvent.Camera) +
+				"&labels=" + url.QueryEscape(FrigateEvent.Label) + "&zones=" + url.QueryEscape(strings.Join(GetTagList(FrigateEvent.Zones), ",")),
+			General:  instance.ExternalURL,
+			Clip:     ClipURL(FrigateEvent),
+			Snapshot: instance.ExternalURL + "/api/events/" + FrigateEvent.ID + "/snapshot.jpg",
+		},
+	}
+	for _, zone := range FrigateEvent.Zones {
+		if zone, ok := zone.(string); ok {
+			data.Zones = append(data.Zones, zone)
+		}
+	}
+	if data.Path == nil {
+		data.Path = []string{}
+	}
+	if !data.InProgress {
+		data.End = time.Unix(int64(FrigateEvent.EndTime), 0)
+		data.Duration = time.Duration((FrigateEvent.EndTime - FrigateEvent.StartTime) * float64(time.Second))
+	}
+	return data
+}
+
+// LoadTemplate returns text of named template, file of MESSAGE_TEMPLATE_DIR
+// takes precedence over built-in template
+func LoadTemplate(Name string) (string, error) {
+	conf := config.New()
+	if conf.MessageTemplateDir != "" {
+		data, err := os.ReadFile(filepath.Join(conf.MessageTemplateDir, filepath.Base(Name)+".tmpl"))
+		if err == nil {
+			return userTemplate(string(data)), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	if text, ok := builtinTemplates[Name]; ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("template %s not found", Name)
+}
+
+// MessageTemplate returns template of event message for camera: the first
+// matching MESSAGE_TEMPLATES rule, MESSAGE_TEMPLATE or Default template
+func MessageTemplate(Instance string, Camera string, Default string) (string, error) {
+	conf := config.New()
+	if len(conf.MessageTemplates) != 1 || conf.MessageTemplates[0] != "None" {
+		for _, rule := range conf.MessageTemplates {
+			camera, name, ok := strings.Cut(strings.TrimSpace(rule), ":")
+			if !ok {
+				log.Warn.Println("Wrong message template rule: " + rule)
+				continue
+			}
+			if CameraMatches(camera, Instance, Camera) {
+				return LoadTemplate(name)
+			}
+		}
+	}
+	if conf.MessageTemplate != "" {
+		if path, ok := strings.CutPrefix(conf.MessageTemplate, "file:"); ok {
+			data, err := os.ReadFile(path)
+			return userTemplate(string(data)), err
+		}
+		return userTemplate(conf.MessageTemplate), nil
+	}
+	return LoadTemplate(Default)
+}
+
+// Template formats of MESSAGE_TEMPLATE_FORMAT
+const (
+	TemplateFormatHTML = "html"
+	// Telegram Markdown of templates written before HTML mode
+	TemplateFormatMarkdown = "markdown"
+)
+
+var (
+	templateAction  = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	markdownLink    = regexp.MustCompile(`\[([^\]\n]*)\]\(([^)\n]*)\)`)
+	markdownCode    = regexp.MustCompile("`([^`]*)`")
+	markdownBold    = regexp.MustCompile(`\*([^*\n]+)\*`)
+	markdownItalic  = regexp.MustCompile(`(^|[^\pL\pN])_([^_\n]+)_($|[^\pL\pN])`)
+	htmlTag         = regexp.MustCompile(`</?[a-z]+[^>]*>`)
+	legacyTemplates sync.Map
+)
+
+// userTemplate converts user template of MESSAGE_TEMPLATE_FORMAT to HTML.
+// Template without HTML tags but with Markdown markup is reported once, it
+// was written for Markdown mode of previous versions.
+func userTemplate(Text string) string {
+	if config.New().MessageTemplateFormat == TemplateFormatMarkdown {
+		return MarkdownTemplate(Text)
+	}
+	if IsLegacyTemplate(Text) {
+		if _, warned := legacyTemplates.LoadOrStore(Text, true); !warned {
+			log.Warn.Println("Message template looks like Markdown, messages are HTML now: set MESSAGE_TEMPLATE_FORMAT=markdown or rewrite the template with HTML tags")
+		}
+	}
+	return Text
+}
+
+// IsLegacyTemplate checks whether template uses Markdown markup and no HTML tags
+func IsLegacyTemplate(Text string) bool {
+	text := templateAction.ReplaceAllString(Text, "")
+	if htmlTag.MatchString(text) {
+		return false
+	}
+	return markdownLink.MatchString(text) || markdownCode.MatchString(text) || markdownBold.MatchString(text)
+}
+
+// MarkdownTemplate converts template of Telegram Markdown to HTML: static
+// text is escaped, `*bold*`, `_italic_`, code and `[text](url)` become tags.
+// Template actions are kept as is, html/template escapes their output.
+func MarkdownTemplate(Text string) string {
+	var actions []string
+	text := templateAction.ReplaceAllStringFunc(Text, func(Action string) string {
+		actions = append(actions, Action)
+		return "\x00" + strconv.Itoa(len(actions)-1) + "\x00"
+	})
+	text = markup.Escape(text)
+	text = markdownLink.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = markdownCode.ReplaceAllString(text, "<code>$1</code>")
+	text = markdownBold.ReplaceAllString(text, "<b>$1</b>")
+	text = markdownItalic.ReplaceAllString(text, "$1<i>$2</i>$3")
+	for i, action := range actions {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", action, 1)
+	}
+	return text
+}
+
+// RenderTemplate renders template text with event model. Templates are
+// Telegram HTML, dynamic fields are escaped by html/template.
+func RenderTemplate(Text string, Data MessageData) (string, error) {
+	tmpl, err := template.New("message").Funcs(templateFuncs(Data.Lang)).Parse(Text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, Data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderEventMessage renders message of event in language of chat
+func RenderEventMessage(FrigateEvent EventStruct, Path []string, Default string) string {
+	return RenderMessage(NewMessageData(FrigateEvent, Path, i18n.Language(config.New().TelegramChatID)), Default)
+}
+
+// RenderMessage renders event model with template selected for camera.
+// Built-in Default template is used if user template fails.
+func RenderMessage(Data MessageData, Default string) string {
+	text, err := MessageTemplate(Data.Instance, Data.Camera, Default)
+	if err == nil {
+		var message string
+		message, err = RenderTemplate(text, Data)
+		if err == nil {
+			return message
+		}
+	}
+	log.Warn.Println("Error rendering message template for event " + Data.ID + ": " + err.Error())
+	message, err := RenderTemplate(builtinTemplates[Default], Data)
+	if err != nil {
+		log.Error.Println("Error rendering built-in template " + Default + ": " + err.Error())
+	}
+	return message
+}
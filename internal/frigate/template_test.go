@@ -0,0 +1,91 @@
+package frigate
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files of message templates")
+
+// testMessageData returns template model of fixed event in fixed timezone
+func testMessageData(t *testing.T) MessageData {
+	t.Setenv("TIMEZONE", "Europe/Berlin")
+	t.Setenv("TIME_FORMAT", "2006-01-02 15:04:05")
+	t.Setenv("FRIGATE_URL", "http://frigate:5000")
+	t.Setenv("FRIGATE_EXTERNAL_URL", "https://frigate.example.com")
+	t.Setenv("MESSAGE_TEMPLATE", "")
+	t.Setenv("MESSAGE_TEMPLATES", "None")
+	t.Setenv("MESSAGE_TEMPLATE_DIR", "")
+	event := EventStruct{
+		ID:        "1714580000.123456-ab12cd",
+		Camera:    "front_door",
+		Label:     "person",
+		StartTime: 1714580000.5,
+		EndTime:   1714580042.5,
+		Zones:     []any{"porch", "driveway"},
+	}
+	event.Data.TopScore = 0.8712
+	return NewMessageData(event, []string{"garage", "front_door"}, "en")
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	for _, name := range []string{TemplateFull, TemplateShort, TemplateText} {
+		t.Run(name, func(t *testing.T) {
+			got := RenderMessage(testMessageData(t), name)
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("template %s:\ngot:\n%s\nwant:\n%s", name, got, want)
+			}
+		})
+	}
+}
+
+func TestBrokenTemplateFallback(t *testing.T) {
+	data := testMessageData(t)
+	want := RenderMessage(data, TemplateShort)
+	for _, broken := range []string{
+		// Parse error
+		`{{if .Camera}}unclosed`,
+		// Execution error
+		`{{.Unknown}}`,
+	} {
+		t.Setenv("MESSAGE_TEMPLATE", broken)
+		if got := RenderMessage(data, TemplateShort); got != want {
+			t.Errorf("template %q: got %q, want built-in template %q", broken, got, want)
+		}
+	}
+}
+
+func TestMarkdownTemplate(t *testing.T) {
+	legacy := "*Camera* `{{.Camera}}` & _{{.Label}}_ on front_door\n[Clip]({{.URL.Clip}})"
+	want := `<b>Camera</b> <code>{{.Camera}}</code> &amp; <i>{{.Label}}</i> on front_door` + "\n" + `<a href="{{.URL.Clip}}">Clip</a>`
+	if got := MarkdownTemplate(legacy); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !IsLegacyTemplate(legacy) {
+		t.Errorf("got template %q not detected as Markdown", legacy)
+	}
+	for _, name := range []string{TemplateFull, TemplateShort, TemplateText} {
+		if IsLegacyTemplate(builtinTemplates[name]) {
+			t.Errorf("got built-in template %s detected as Markdown", name)
+		}
+	}
+
+	data := testMessageData(t)
+	t.Setenv("MESSAGE_TEMPLATE_FORMAT", TemplateFormatMarkdown)
+	t.Setenv("MESSAGE_TEMPLATE", "*{{.Camera}}* <{{.Label}}>")
+	if got, want := RenderMessage(data, TemplateShort), "<b>front_door</b> &lt;person&gt;"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
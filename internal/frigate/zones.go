@@ -0,0 +1,144 @@
+package frigate
+
+import (
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+const (
+	ZoneTransitionEnter = "enter"
+	ZoneTransitionExit  = "exit"
+	ZoneTransitionBoth  = "both"
+)
+
+// GetZoneList returns raw zone names without nil items
+func GetZoneList(Zones []any) []string {
+	var zones []string
+	for _, zone := range Zones {
+		if zone != nil {
+			zones = append(zones, zone.(string))
+		}
+	}
+	return zones
+}
+
+// CurrentZones returns zones where the object of the event is located now.
+// Old Frigate versions don't send current_zones, then zones list is used.
+// Finished event is not located in any zone.
+func CurrentZones(FrigateEvent EventStruct) []string {
+	if FrigateEvent.EndTime != 0 {
+		return []string{}
+	}
+	if FrigateEvent.CurrentZones != nil {
+		return GetZoneList(FrigateEvent.CurrentZones)
+	}
+	if FrigateEvent.EnteredZones != nil {
+		return GetZoneList(FrigateEvent.EnteredZones)
+	}
+	return GetZoneList(FrigateEvent.Zones)
+}
+
+// ZoneTransitionEnabled checks ZONE_TRANSITIONS rules.
+// Rule format is camera:zone:transition, camera or zone can be `*`.
+func ZoneTransitionEnabled(Camera string, Zone string, Transition string) bool {
+	conf := config.New()
+	if len(conf.ZoneTransitions) == 1 && conf.ZoneTransitions[0] == "None" {
+		return false
+	}
+	for _, rule := range conf.ZoneTransitions {
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		if len(parts) != 3 {
+			log.Warn.Println("Wrong zone transition rule: " + rule)
+			continue
+		}
+		if parts[0] != "*" && parts[0] != Camera {
+			continue
+		}
+		if parts[1] != "*" && parts[1] != Zone {
+			continue
+		}
+		if parts[2] == ZoneTransitionBoth || parts[2] == Transition {
+			return true
+		}
+	}
+	return false
+}
+
+// ZonesDiff returns items from first slice which are missing in second slice
+func ZonesDiff(First []string, Second []string) []string {
+	var diff []string
+	for _, zone := range First {
+		if !StringsContains(zone, Second) {
+			diff = append(diff, zone)
+		}
+	}
+	return diff
+}
+
+// TrackEventZones compares event zones with zones saved on previous
+// update and runs zone based notifications. Zones are swapped in Redis
+// transaction, so every transition is seen by one poller only.
+func TrackEventZones(FrigateEvent EventStruct, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	transitions := !(len(conf.ZoneTransitions) == 1 && conf.ZoneTransitions[0] == "None")
+	loitering := !(len(conf.LoiteringZones) == 1 && conf.LoiteringZones[0] == "None")
+	if !transitions && !loitering {
+		return
+	}
+
+	current := CurrentZones(FrigateEvent)
+	previous, tracked := redis.SwapEventZones(EventKey(FrigateEvent), current, time.Duration(conf.RedisTTL)*time.Second)
+
+	if transitions {
+		ProcessZoneTransitions(FrigateEvent, previous, current, tracked, bot)
+	}
+	if loitering {
+		ProcessLoitering(FrigateEvent, previous, current, tracked, bot)
+	}
+}
+
+// ProcessZoneTransitions sends message for every configured enter/exit transition.
+func ProcessZoneTransitions(FrigateEvent EventStruct, Previous []string, Current []string, Tracked bool, bot *tgbotapi.BotAPI) {
+	// Event seen first time after finish, nothing to compare
+	if !Tracked && FrigateEvent.EndTime != 0 {
+		return
+	}
+
+	for _, zone := range ZonesDiff(Current, Previous) {
+		if ZoneTransitionEnabled(FrigateEvent.Camera, zone, ZoneTransitionEnter) {
+			SendZoneTransition(FrigateEvent, zone, ZoneTransitionEnter, bot)
+		}
+	}
+	for _, zone := range ZonesDiff(Previous, Current) {
+		if ZoneTransitionEnabled(FrigateEvent.Camera, zone, ZoneTransitionExit) {
+			SendZoneTransition(FrigateEvent, zone, ZoneTransitionExit, bot)
+		}
+	}
+}
+
+func SendZoneTransition(FrigateEvent EventStruct, Zone string, Transition string, bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	lang := i18n.Language(conf.TelegramChatID)
+	key := "zone_entered"
+	if Transition == ZoneTransitionExit {
+		key = "zone_left"
+	}
+	text := i18n.T(lang, key, "#"+NormalizeTagText(FrigateEvent.Label), "#"+NormalizeTagText(Zone),
+		"#"+NormalizeTagText(FrigateEvent.Camera)+InstanceTag(FrigateEvent)) + "\n"
+	text += "┗<b>" + i18n.T(lang, "event_id") + "</b> " + markup.Code(FrigateEvent.ID)
+	log.Debug.Println("Zone transition: " + FrigateEvent.ID + " " + Transition + " " + Zone)
+
+	msg := tgbotapi.NewMessage(conf.TelegramChatID, text)
+	msg.ParseMode = markup.ParseMode
+	msg.DisableNotification = redis.GetStateMuteEvent()
+	if _, err := markup.Send(bot, msg); err != nil {
+		log.Error.Println(err.Error())
+	}
+}
@@ -0,0 +1,253 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+const (
+	StatusSent      = "sent"
+	StatusSkipped   = "skipped"
+	StatusCollapsed = "collapsed"
+	StatusDigest    = "digest"
+	StatusBacklog   = "backlog"
+)
+
+// Kinds of sent messages
+const (
+	MediaPhoto   = "photo"
+	MediaPreview = "preview"
+	MediaClip    = "clip"
+	MediaLink    = "link"
+	MediaText    = "text"
+)
+
+// Message is Telegram message sent for event
+type Message struct {
+	Chat int64 `json:"chat"`
+	ID   int   `json:"id"`
+	// What was sent: photo, preview, clip, link or text. Message with clip
+	// link is saved twice, as photo or text and as link.
+	Kind string `json:"kind"`
+}
+
+// Record is event processed by bot
+type Record struct {
+	Key       string    `json:"key"`
+	Instance  string    `json:"instance,omitempty"`
+	ID        string    `json:"id"`
+	Camera    string    `json:"camera"`
+	Label     string    `json:"label"`
+	Zones     []string  `json:"zones"`
+	Score     float64   `json:"score"`
+	StartTime float64   `json:"start_time"`
+	EndTime   float64   `json:"end_time"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	Chats     []int64   `json:"chats,omitempty"`
+	Messages  []Message `json:"sent,omitempty"`
+	Updated   int64     `json:"updated"`
+}
+
+// Filter of history query
+type Filter struct {
+	Instance string
+	Camera   string
+	Label    string
+	Status   string
+	Since    time.Duration
+	Limit    int
+}
+
+// Get record by key
+func Get(Key string) (Record, bool) {
+	var record Record
+	val, ok := redis.GetHistory(Key)
+	if !ok {
+		return record, false
+	}
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		log.Error.Println("Error unmarshal history record: " + err.Error())
+		return record, false
+	}
+	return record, true
+}
+
+// Exists checks event is already in history
+func Exists(Key string) bool {
+	_, ok := redis.GetHistory(Key)
+	return ok
+}
+
+// Save record, chats and messages of previous record of the event are kept
+func Save(Record Record) {
+	conf := config.New()
+	if previous, ok := Get(Record.Key); ok {
+		Record.Messages = mergeMessages(previous.Messages, Record.Messages)
+	}
+	Record.Chats = messageChats(Record.Messages)
+	Record.Updated = time.Now().Unix()
+	data, err := json.Marshal(Record)
+	if err != nil {
+		log.Error.Println("Error marshal history record: " + err.Error())
+		return
+	}
+	redis.AddHistory(Record.Key, string(data), Record.StartTime, time.Duration(conf.RedisTTL)*time.Second)
+}
+
+// mergeMessages appends new messages, message sent again after update of
+// event is saved once
+func mergeMessages(Previous []Message, Messages []Message) []Message {
+	merged := append([]Message{}, Previous...)
+	for _, message := range Messages {
+		found := false
+		for _, m := range merged {
+			if m == message {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, message)
+		}
+	}
+	return merged
+}
+
+// messageChats returns chats of messages, every chat once
+func messageChats(Messages []Message) []int64 {
+	var chats []int64
+	for _, message := range Messages {
+		found := false
+		for _, chat := range chats {
+			if chat == message.Chat {
+				found = true
+				break
+			}
+		}
+		if !found {
+			chats = append(chats, message.Chat)
+		}
+	}
+	return chats
+}
+
+func containsKind(Kinds []string, Kind string) bool {
+	for _, kind := range Kinds {
+		if kind == Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Query returns records matching filter, newest first
+func Query(Filter Filter) []Record {
+	var records []Record
+	since := float64(time.Now().Add(-Filter.Since).Unix())
+	for _, key := range redis.GetHistoryKeys(since) {
+		record, ok := Get(key)
+		if !ok {
+			continue
+		}
+		if (Filter.Instance != "" && record.Instance != Filter.Instance) ||
+			(Filter.Camera != "" && record.Camera != Filter.Camera) ||
+			(Filter.Label != "" && record.Label != Filter.Label) ||
+			(Filter.Status != "" && record.Status != Filter.Status) {
+			continue
+		}
+		records = append(records, record)
+		if Filter.Limit > 0 && len(records) >= Filter.Limit {
+			break
+		}
+	}
+	return records
+}
+
+// ParseDuration parses Go duration with additional `d` days unit
+func ParseDuration(Value string) (time.Duration, error) {
+	if strings.HasSuffix(Value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(Value, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(Value)
+}
+
+// ParseFilter parses `key=value` arguments, e.g. `camera=porch label=person since=2h`
+func ParseFilter(Args string, Limit int) (Filter, error) {
+	filter := Filter{Since: 24 * time.Hour, Limit: Limit}
+	for _, arg := range strings.Fields(Args) {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return filter, i18n.NewError("wrong_argument", arg)
+		}
+		switch key {
+		case "instance":
+			filter.Instance = value
+		case "camera":
+			filter.Camera = value
+		case "label":
+			filter.Label = value
+		case "status":
+			filter.Status = value
+		case "since":
+			since, err := ParseDuration(value)
+			if err != nil {
+				return filter, err
+			}
+			filter.Since = since
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return filter, err
+			}
+			filter.Limit = limit
+		default:
+			return filter, i18n.NewError("unknown_argument", key)
+		}
+	}
+	return filter, nil
+}
+
+// Format returns records as HTML text for telegram in Lang
+func Format(Records []Record, Lang string) string {
+	if len(Records) == 0 {
+		return i18n.T(Lang, "no_events_found")
+	}
+	text := ""
+	for _, record := range Records {
+		camera := record.Camera
+		if record.Instance != "" {
+			camera = record.Instance + "/" + camera
+		}
+		text += fmt.Sprintf("%s %s %s <code>%.0f%%</code> %s",
+			markup.Code(i18n.LocalTime(time.Unix(int64(record.StartTime), 0)).Format("01-02 15:04:05")),
+			markup.Escape(camera), markup.Escape(record.Label), record.Score*100, markup.Bold(record.Status))
+		if record.Reason != "" {
+			text += " (" + markup.Escape(record.Reason) + ")"
+		}
+		var kinds []string
+		for _, message := range record.Messages {
+			if !containsKind(kinds, message.Kind) {
+				kinds = append(kinds, message.Kind)
+			}
+		}
+		if len(kinds) != 0 {
+			text += " " + markup.Escape(strings.Join(kinds, ", "))
+		}
+		text += "\n"
+	}
+	return text
+}
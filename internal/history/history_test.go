@@ -0,0 +1,27 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeMessages(t *testing.T) {
+	previous := []Message{{Chat: 1, ID: 10, Kind: MediaPhoto}, {Chat: 1, ID: 11, Kind: MediaClip}}
+	update := []Message{{Chat: 1, ID: 10, Kind: MediaPhoto}, {Chat: 2, ID: 20, Kind: MediaText}, {Chat: 1, ID: 10, Kind: MediaLink}}
+	got := mergeMessages(previous, update)
+	want := []Message{
+		{Chat: 1, ID: 10, Kind: MediaPhoto},
+		{Chat: 1, ID: 11, Kind: MediaClip},
+		{Chat: 2, ID: 20, Kind: MediaText},
+		{Chat: 1, ID: 10, Kind: MediaLink},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got messages %v, want %v", got, want)
+	}
+	if len(previous) != 2 {
+		t.Fatalf("previous messages are modified: %v", previous)
+	}
+	if chats := messageChats(got); !reflect.DeepEqual(chats, []int64{1, 2}) {
+		t.Fatalf("got chats %v, want [1 2]", chats)
+	}
+}
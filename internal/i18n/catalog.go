@@ -0,0 +1,481 @@
+package i18n
+
+// catalogs are translations by language and key. Values with verbs are
+// formatted by T, arguments are escaped by caller.
+var catalogs = map[string]map[string]string{
+	"en": {
+		// Event message
+		"event":       "Event",
+		"new_event":   "New event",
+		"camera":      "Camera",
+		"label":       "Label",
+		"start_time":  "Start time",
+		"end_time":    "End time",
+		"in_progress": "In progress",
+		"lasted":      "lasted %s",
+		"duration":    "Duration",
+		"top_score":   "Top score",
+		"event_id":    "Event id",
+		"zones":       "Zones",
+		"path":        "Path",
+		"urls":        "URLs",
+		"events":      "Events",
+		"general":     "General",
+		"source_clip": "Source clip",
+		"event_url":   "Event URL",
+		"detected":    "%s detected on %s at %s",
+		"clip":        "Clip",
+		"download":    "download",
+
+		// Large clips
+		"clip_transcoded":     "transcoded to fit Telegram limit",
+		"clip_split":          "split into %d parts",
+		"clip_trimmed":        "trimmed to first %s",
+		"clip_too_large":      "too large for Telegram",
+		"clip_private":        "not sent, camera has privacy regions",
+		"recording_too_large": "Clip is too large for Telegram",
+		"clip_not_ready":      "Event is in progress, clip is not ready",
+		"part":                "part %d/%d",
+
+		// Notifications
+		"zone_entered":       "%s entered %s on %s",
+		"zone_left":          "%s left %s on %s",
+		"loitering":          "%s is in %s on %s for %s",
+		"loitering_reminder": "%s still in %s after %s",
+		"left_after":         "Left after",
+		"more_detections":    "+%s more detections",
+		"bot_down":           "Bot was down for",
+		"since":              "Since",
+		"no_events_missed":   "No events missed",
+		"missed_events":      "Missed %d events",
+		"skipped_backlog":    "Skipped %d events older than",
+
+		// Reports and history
+		"report_for":        "Report for %s",
+		"from":              "From",
+		"to":                "To",
+		"total_events":      "Total events",
+		"events_per_camera": "Events per camera",
+		"busiest_hours":     "Busiest hours",
+		"longest_events":    "Longest events",
+		"clip_link":         "clip",
+		"no_events_found":   "No events found",
+		"page":              "page %d",
+		"prev":              "Prev",
+		"next":              "Next",
+
+		// Cameras
+		"cameras":     "Cameras",
+		"unreachable": "%s is unreachable",
+		"status":      "Status",
+		"online":      "online",
+		"offline":     "offline",
+		"detect":      "Detect",
+		"record":      "Record",
+		"snapshots":   "Snapshots",
+		"objects":     "Objects",
+		"on":          "on",
+		"off":         "off",
+
+		// Commands
+		"help_stop":        "Stop send events",
+		"help_resume":      "Resume send events",
+		"help_mute":        "Mute send events",
+		"help_unmute":      "Unmute send events",
+		"help_status":      "Current status",
+		"help_cameras":     "Cameras status",
+		"help_camera":      "Camera details",
+		"help_snapshot":    "Camera snapshot",
+		"help_clip":        "Recording clip",
+		"help_events":      "Events browser",
+		"help_report":      "Events report",
+		"help_history":     "Events history",
+		"help_language":    "Language",
+		"help_chat":        "Comand working only in chat id: %s (Current chat)",
+		"status_send":      "Send event",
+		"status_mute":      "Mute event",
+		"status_language":  "Language",
+		"stopped":          "Stop send message.",
+		"resumed":          "Resume send message.",
+		"muted":            "Mute send message.",
+		"unmuted":          "Unmute send message.",
+		"redis_error":      "Error setting value, check logs.",
+		"unknown_command":  "I don't know that command",
+		"usage":            "Usage: %s",
+		"language_current": "Language: %s, available: %s",
+		"language_unknown": "Unknown language, available: %s",
+		"language_set":     "Language is set to English",
+
+		// Errors
+		"clip_usage":           "Usage: /clip <camera> <from> <duration>, e.g. /clip porch 10 minutes ago 5m or /clip porch 18:30 90s",
+		"events_usage":         "Usage: /events [camera] [label] [N]",
+		"snapshot_usage":       "Usage: /snapshot <camera|all> [bbox] [height=N] [quality=N]",
+		"events_count":         "Count of events must be from 1 to %d",
+		"wrong_name":           "Wrong name: %s",
+		"wrong_value":          "Wrong value of %s: %s",
+		"unknown_argument":     "Unknown argument: %s",
+		"wrong_argument":       "Wrong argument, expected key=value: %s",
+		"unknown_period":       "Unknown report period: %s",
+		"wrong_callback":       "Wrong callback data",
+		"button_expired":       "Button is expired",
+		"unknown_camera":       "Unknown camera: %s",
+		"unknown_instance":     "Unknown Frigate instance: %s",
+		"wrong_time":           "Can't parse time: %s",
+		"wrong_duration":       "Can't parse duration: %s",
+		"unknown_unit":         "Unknown duration unit: %s",
+		"duration_positive":    "Duration must be positive",
+		"duration_too_long":    "Duration is longer than %s",
+		"clip_in_future":       "Clip starts in future",
+		"error_recording":      "Error getting recording",
+		"error_send_clip":      "Error sending clip",
+		"error_clip":           "Error getting clip",
+		"error_frigate_config": "Error getting Frigate config",
+		"error_frigate_stats":  "Error getting Frigate stats",
+		"error_send_camera":    "Error sending camera details",
+		"error_events":         "Error getting events",
+		"error_events_page":    "Error editing events page",
+		"error_event":          "Error getting event",
+		"error_send_event":     "Error sending event",
+		"error_snapshot":       "Error getting snapshot",
+		"error_send_snapshot":  "Error sending snapshot",
+		"error_collage":        "Error encoding snapshots collage",
+		"error_refresh":        "Error refreshing snapshot",
+		"error_report":         "Error sending report",
+		"error_filter":         "Error parsing filter",
+		"no_snapshots":         "No snapshots received",
+		"refresh":              "Refresh",
+		"refreshed":            "Refreshed",
+		"health_unreachable":   "Frigate is unreachable",
+		"health_no_frames":     "Camera %s doesn't receive frames (fps 0)",
+		"health_inference":     "Detector %s inference speed is %.1f ms",
+		"health_storage":       "Storage %s usage is %.1f%%",
+		"recovered":            "Recovered",
+		"chart_hours":          "Events per hour",
+		"chart_heatmap":        "Events by weekday and hour",
+		"weekday_mon":          "Mon",
+		"weekday_tue":          "Tue",
+		"weekday_wed":          "Wed",
+		"weekday_thu":          "Thu",
+		"weekday_fri":          "Fri",
+		"weekday_sat":          "Sat",
+		"weekday_sun":          "Sun",
+
+		// Durations
+		"unit_day":    "d",
+		"unit_hour":   "h",
+		"unit_minute": "m",
+		"unit_second": "s",
+	},
+	"ru": {
+		"event":       "Событие",
+		"new_event":   "Новое событие",
+		"camera":      "Камера",
+		"label":       "Объект",
+		"start_time":  "Начало",
+		"end_time":    "Конец",
+		"in_progress": "В процессе",
+		"lasted":      "длилось %s",
+		"duration":    "Длительность",
+		"top_score":   "Точность",
+		"event_id":    "ID события",
+		"zones":       "Зоны",
+		"path":        "Путь",
+		"urls":        "Ссылки",
+		"events":      "События",
+		"general":     "Frigate",
+		"source_clip": "Исходное видео",
+		"event_url":   "Ссылка на событие",
+		"detected":    "%s обнаружен на %s в %s",
+		"clip":        "Видео",
+		"download":    "скачать",
+
+		"clip_transcoded":     "пережато под лимит Telegram",
+		"clip_split":          "разделено на %d частей",
+		"clip_trimmed":        "обрезано до первых %s",
+		"clip_too_large":      "слишком большое для Telegram",
+		"clip_private":        "не отправлено, у камеры есть приватные зоны",
+		"recording_too_large": "Видео слишком большое для Telegram",
+		"clip_not_ready":      "Событие продолжается, видео ещё не готово",
+		"part":                "часть %d/%d",
+
+		"zone_entered":       "%s вошёл в %s на %s",
+		"zone_left":          "%s покинул %s на %s",
+		"loitering":          "%s находится в %s на %s уже %s",
+		"loitering_reminder": "%s всё ещё в %s спустя %s",
+		"left_after":         "Ушёл через",
+		"more_detections":    "+%s обнаружений",
+		"bot_down":           "Бот не работал",
+		"since":              "С",
+		"no_events_missed":   "Пропущенных событий нет",
+		"missed_events":      "Пропущено событий: %d",
+		"skipped_backlog":    "Пропущено событий: %d, старше чем",
+
+		"report_for":        "Отчёт за %s",
+		"from":              "С",
+		"to":                "По",
+		"total_events":      "Всего событий",
+		"events_per_camera": "События по камерам",
+		"busiest_hours":     "Самые активные часы",
+		"longest_events":    "Самые долгие события",
+		"clip_link":         "видео",
+		"no_events_found":   "События не найдены",
+		"page":              "страница %d",
+		"prev":              "Назад",
+		"next":              "Вперёд",
+
+		"cameras":     "Камеры",
+		"unreachable": "%s недоступен",
+		"status":      "Статус",
+		"online":      "в сети",
+		"offline":     "не в сети",
+		"detect":      "Детекция",
+		"record":      "Запись",
+		"snapshots":   "Снимки",
+		"objects":     "Объекты",
+		"on":          "вкл",
+		"off":         "выкл",
+
+		"help_stop":        "Остановить отправку событий",
+		"help_resume":      "Возобновить отправку событий",
+		"help_mute":        "Отправлять события без звука",
+		"help_unmute":      "Отправлять события со звуком",
+		"help_status":      "Текущий статус",
+		"help_cameras":     "Статус камер",
+		"help_camera":      "Информация о камере",
+		"help_snapshot":    "Снимок камеры",
+		"help_clip":        "Видео из записи",
+		"help_events":      "Просмотр событий",
+		"help_report":      "Отчёт о событиях",
+		"help_history":     "История событий",
+		"help_language":    "Язык",
+		"help_chat":        "Команды работают только в чате с id: %s (текущий чат)",
+		"status_send":      "Отправка событий остановлена",
+		"status_mute":      "Без звука",
+		"status_language":  "Язык",
+		"stopped":          "Отправка событий остановлена.",
+		"resumed":          "Отправка событий возобновлена.",
+		"muted":            "События отправляются без звука.",
+		"unmuted":          "События отправляются со звуком.",
+		"redis_error":      "Ошибка сохранения, проверьте логи.",
+		"unknown_command":  "Неизвестная команда",
+		"usage":            "Использование: %s",
+		"language_current": "Язык: %s, доступны: %s",
+		"language_unknown": "Неизвестный язык, доступны: %s",
+		"language_set":     "Выбран русский язык",
+
+		// Errors
+		"clip_usage":           "Использование: /clip <камера> <начало> <длительность>, например /clip porch 10 minutes ago 5m или /clip porch 18:30 90s",
+		"events_usage":         "Использование: /events [камера] [объект] [N]",
+		"snapshot_usage":       "Использование: /snapshot <камера|all> [bbox] [height=N] [quality=N]",
+		"events_count":         "Количество событий должно быть от 1 до %d",
+		"wrong_name":           "Неверное имя: %s",
+		"wrong_value":          "Неверное значение %s: %s",
+		"unknown_argument":     "Неизвестный аргумент: %s",
+		"wrong_argument":       "Неверный аргумент, ожидается key=value: %s",
+		"unknown_period":       "Неизвестный период отчёта: %s",
+		"wrong_callback":       "Неверные данные кнопки",
+		"button_expired":       "Кнопка устарела",
+		"unknown_camera":       "Неизвестная камера: %s",
+		"unknown_instance":     "Неизвестный экземпляр Frigate: %s",
+		"wrong_time":           "Не удалось разобрать время: %s",
+		"wrong_duration":       "Не удалось разобрать длительность: %s",
+		"unknown_unit":         "Неизвестная единица длительности: %s",
+		"duration_positive":    "Длительность должна быть больше нуля",
+		"duration_too_long":    "Длительность больше %s",
+		"clip_in_future":       "Видео начинается в будущем",
+		"error_recording":      "Ошибка получения записи",
+		"error_send_clip":      "Ошибка отправки видео",
+		"error_clip":           "Ошибка получения видео",
+		"error_frigate_config": "Ошибка получения конфигурации Frigate",
+		"error_frigate_stats":  "Ошибка получения статистики Frigate",
+		"error_send_camera":    "Ошибка отправки данных камеры",
+		"error_events":         "Ошибка получения событий",
+		"error_events_page":    "Ошибка обновления страницы событий",
+		"error_event":          "Ошибка получения события",
+		"error_send_event":     "Ошибка отправки события",
+		"error_snapshot":       "Ошибка получения снимка",
+		"error_send_snapshot":  "Ошибка отправки снимка",
+		"error_collage":        "Ошибка создания коллажа снимков",
+		"error_refresh":        "Ошибка обновления снимка",
+		"error_report":         "Ошибка отправки отчёта",
+		"error_filter":         "Ошибка разбора фильтра",
+		"no_snapshots":         "Снимки не получены",
+		"refresh":              "Обновить",
+		"refreshed":            "Обновлено",
+		"health_unreachable":   "Frigate недоступен",
+		"health_no_frames":     "Камера %s не получает кадры (fps 0)",
+		"health_inference":     "Скорость детектора %s %.1f мс",
+		"health_storage":       "Хранилище %s заполнено на %.1f%%",
+		"recovered":            "Восстановлено",
+		"chart_hours":          "События по часам",
+		"chart_heatmap":        "События по дням недели и часам",
+		"weekday_mon":          "Пн",
+		"weekday_tue":          "Вт",
+		"weekday_wed":          "Ср",
+		"weekday_thu":          "Чт",
+		"weekday_fri":          "Пт",
+		"weekday_sat":          "Сб",
+		"weekday_sun":          "Вс",
+
+		"unit_day":    "д",
+		"unit_hour":   "ч",
+		"unit_minute": "мин",
+		"unit_second": "с",
+	},
+	"de": {
+		"event":       "Ereignis",
+		"new_event":   "Neues Ereignis",
+		"camera":      "Kamera",
+		"label":       "Objekt",
+		"start_time":  "Beginn",
+		"end_time":    "Ende",
+		"in_progress": "Läuft",
+		"lasted":      "Dauer %s",
+		"duration":    "Dauer",
+		"top_score":   "Höchste Wertung",
+		"event_id":    "Ereignis-ID",
+		"zones":       "Zonen",
+		"path":        "Weg",
+		"urls":        "Links",
+		"events":      "Ereignisse",
+		"general":     "Frigate",
+		"source_clip": "Originalclip",
+		"event_url":   "Ereignis-Link",
+		"detected":    "%s erkannt auf %s um %s",
+		"clip":        "Clip",
+		"download":    "herunterladen",
+
+		"clip_transcoded":     "für das Telegram-Limit neu kodiert",
+		"clip_split":          "in %d Teile geteilt",
+		"clip_trimmed":        "auf die ersten %s gekürzt",
+		"clip_too_large":      "zu groß für Telegram",
+		"clip_private":        "nicht gesendet, Kamera hat Privatsphäre-Bereiche",
+		"recording_too_large": "Clip ist zu groß für Telegram",
+		"clip_not_ready":      "Ereignis läuft noch, der Clip ist nicht fertig",
+		"part":                "Teil %d/%d",
+
+		"zone_entered":       "%s hat %s auf %s betreten",
+		"zone_left":          "%s hat %s auf %s verlassen",
+		"loitering":          "%s ist in %s auf %s seit %s",
+		"loitering_reminder": "%s ist noch in %s nach %s",
+		"left_after":         "Verlassen nach",
+		"more_detections":    "+%s weitere Erkennungen",
+		"bot_down":           "Bot war nicht aktiv für",
+		"since":              "Seit",
+		"no_events_missed":   "Keine Ereignisse verpasst",
+		"missed_events":      "%d Ereignisse verpasst",
+		"skipped_backlog":    "%d Ereignisse übersprungen, älter als",
+
+		"report_for":        "Bericht für %s",
+		"from":              "Von",
+		"to":                "Bis",
+		"total_events":      "Ereignisse gesamt",
+		"events_per_camera": "Ereignisse pro Kamera",
+		"busiest_hours":     "Aktivste Stunden",
+		"longest_events":    "Längste Ereignisse",
+		"clip_link":         "Clip",
+		"no_events_found":   "Keine Ereignisse gefunden",
+		"page":              "Seite %d",
+		"prev":              "Zurück",
+		"next":              "Weiter",
+
+		"cameras":     "Kameras",
+		"unreachable": "%s ist nicht erreichbar",
+		"status":      "Status",
+		"online":      "online",
+		"offline":     "offline",
+		"detect":      "Erkennung",
+		"record":      "Aufnahme",
+		"snapshots":   "Schnappschüsse",
+		"objects":     "Objekte",
+		"on":          "an",
+		"off":         "aus",
+
+		"help_stop":        "Senden von Ereignissen stoppen",
+		"help_resume":      "Senden von Ereignissen fortsetzen",
+		"help_mute":        "Ereignisse stumm senden",
+		"help_unmute":      "Ereignisse mit Ton senden",
+		"help_status":      "Aktueller Status",
+		"help_cameras":     "Kamerastatus",
+		"help_camera":      "Kameradetails",
+		"help_snapshot":    "Kamera-Schnappschuss",
+		"help_clip":        "Clip aus Aufnahme",
+		"help_events":      "Ereignisse durchsuchen",
+		"help_report":      "Ereignisbericht",
+		"help_history":     "Ereignisverlauf",
+		"help_language":    "Sprache",
+		"help_chat":        "Befehle funktionieren nur im Chat mit ID: %s (aktueller Chat)",
+		"status_send":      "Senden gestoppt",
+		"status_mute":      "Stumm",
+		"status_language":  "Sprache",
+		"stopped":          "Senden von Ereignissen gestoppt.",
+		"resumed":          "Senden von Ereignissen fortgesetzt.",
+		"muted":            "Ereignisse werden stumm gesendet.",
+		"unmuted":          "Ereignisse werden mit Ton gesendet.",
+		"redis_error":      "Fehler beim Speichern, siehe Logs.",
+		"unknown_command":  "Unbekannter Befehl",
+		"usage":            "Verwendung: %s",
+		"language_current": "Sprache: %s, verfügbar: %s",
+		"language_unknown": "Unbekannte Sprache, verfügbar: %s",
+		"language_set":     "Sprache ist auf Deutsch gesetzt",
+
+		// Errors
+		"clip_usage":           "Verwendung: /clip <Kamera> <Start> <Dauer>, z. B. /clip porch 10 minutes ago 5m oder /clip porch 18:30 90s",
+		"events_usage":         "Verwendung: /events [Kamera] [Objekt] [N]",
+		"snapshot_usage":       "Verwendung: /snapshot <Kamera|all> [bbox] [height=N] [quality=N]",
+		"events_count":         "Anzahl der Ereignisse muss zwischen 1 und %d liegen",
+		"wrong_name":           "Ungültiger Name: %s",
+		"wrong_value":          "Ungültiger Wert für %s: %s",
+		"unknown_argument":     "Unbekanntes Argument: %s",
+		"wrong_argument":       "Falsches Argument, erwartet key=value: %s",
+		"unknown_period":       "Unbekannter Berichtszeitraum: %s",
+		"wrong_callback":       "Ungültige Button-Daten",
+		"button_expired":       "Button ist abgelaufen",
+		"unknown_camera":       "Unbekannte Kamera: %s",
+		"unknown_instance":     "Unbekannte Frigate-Instanz: %s",
+		"wrong_time":           "Zeit kann nicht gelesen werden: %s",
+		"wrong_duration":       "Dauer kann nicht gelesen werden: %s",
+		"unknown_unit":         "Unbekannte Zeiteinheit: %s",
+		"duration_positive":    "Dauer muss positiv sein",
+		"duration_too_long":    "Dauer ist länger als %s",
+		"clip_in_future":       "Clip beginnt in der Zukunft",
+		"error_recording":      "Fehler beim Abrufen der Aufnahme",
+		"error_send_clip":      "Fehler beim Senden des Clips",
+		"error_clip":           "Fehler beim Abrufen des Clips",
+		"error_frigate_config": "Fehler beim Abrufen der Frigate-Konfiguration",
+		"error_frigate_stats":  "Fehler beim Abrufen der Frigate-Statistik",
+		"error_send_camera":    "Fehler beim Senden der Kameradetails",
+		"error_events":         "Fehler beim Abrufen der Ereignisse",
+		"error_events_page":    "Fehler beim Bearbeiten der Ereignisseite",
+		"error_event":          "Fehler beim Abrufen des Ereignisses",
+		"error_send_event":     "Fehler beim Senden des Ereignisses",
+		"error_snapshot":       "Fehler beim Abrufen des Schnappschusses",
+		"error_send_snapshot":  "Fehler beim Senden des Schnappschusses",
+		"error_collage":        "Fehler beim Erstellen der Schnappschuss-Collage",
+		"error_refresh":        "Fehler beim Aktualisieren des Schnappschusses",
+		"error_report":         "Fehler beim Senden des Berichts",
+		"error_filter":         "Fehler beim Lesen des Filters",
+		"no_snapshots":         "Keine Schnappschüsse empfangen",
+		"refresh":              "Aktualisieren",
+		"refreshed":            "Aktualisiert",
+		"health_unreachable":   "Frigate ist nicht erreichbar",
+		"health_no_frames":     "Kamera %s empfängt keine Bilder (fps 0)",
+		"health_inference":     "Inferenzzeit von Detektor %s ist %.1f ms",
+		"health_storage":       "Speicher %s ist zu %.1f%% belegt",
+		"recovered":            "Wiederhergestellt",
+		"chart_hours":          "Ereignisse pro Stunde",
+		"chart_heatmap":        "Ereignisse nach Wochentag und Stunde",
+		"weekday_mon":          "Mo",
+		"weekday_tue":          "Di",
+		"weekday_wed":          "Mi",
+		"weekday_thu":          "Do",
+		"weekday_fri":          "Fr",
+		"weekday_sat":          "Sa",
+		"weekday_sun":          "So",
+
+		"unit_day":    "T",
+		"unit_hour":   "Std",
+		"unit_minute": "Min",
+		"unit_second": "s",
+	},
+}
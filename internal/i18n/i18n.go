@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	// Timezone database is embedded, so TIMEZONE works without tzdata in container
+	_ "time/tzdata"
+
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+// DefaultLanguage is used for missing translations
+const DefaultLanguage = "en"
+
+// Languages returns supported languages
+func Languages() []string {
+	return []string{"en", "ru", "de"}
+}
+
+// Supported checks whether language has catalog
+func Supported(Lang string) bool {
+	_, ok := catalogs[Lang]
+	return ok
+}
+
+// Language returns language of chat: set with /language or LANGUAGE
+func Language(ChatID int64) string {
+	if lang, ok := redis.GetChatLanguage(ChatID); ok && Supported(lang) {
+		return lang
+	}
+	if lang := config.New().Language; Supported(lang) {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// T returns translation of Key, Args are formatted with fmt.Sprintf.
+// English text is used if translation is missing.
+func T(Lang string, Key string, Args ...interface{}) string {
+	text, ok := catalogs[Lang][Key]
+	if !ok {
+		text, ok = catalogs[DefaultLanguage][Key]
+	}
+	if !ok {
+		log.Warn.Println("Missing translation: " + Key)
+		text = Key
+	}
+	if len(Args) != 0 {
+		return fmt.Sprintf(text, Args...)
+	}
+	return text
+}
+
+// Error is error with message of catalog, it is shown in language of chat
+// by ErrorText and in English otherwise
+type Error struct {
+	Key  string
+	Args []interface{}
+}
+
+// NewError returns error with message of catalog
+func NewError(Key string, Args ...interface{}) error {
+	return Error{Key: Key, Args: Args}
+}
+
+func (e Error) Error() string {
+	return T(DefaultLanguage, e.Key, e.Args...)
+}
+
+// ErrorText returns text of error in Lang, error without catalog message is
+// returned as is
+func ErrorText(Lang string, err error) string {
+	var e Error
+	if errors.As(err, &e) {
+		return T(Lang, e.Key, e.Args...)
+	}
+	return err.Error()
+}
+
+// Location returns TIMEZONE location, TZ of container if not set
+func Location() *time.Location {
+	conf := config.New()
+	if conf.Timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		log.Warn.Println("Wrong timezone " + conf.Timezone + ": " + err.Error())
+		return time.Local
+	}
+	return location
+}
+
+// LocalTime converts time to TIMEZONE
+func LocalTime(Time time.Time) time.Time {
+	return Time.In(Location())
+}
+
+// FormatTime formats time with TIME_FORMAT in TIMEZONE
+func FormatTime(Time time.Time) string {
+	return LocalTime(Time).Format(config.New().TimeFormat)
+}
+
+// FormatDuration returns human duration rounded to seconds, e.g. `1h 5m`
+// or `42s`. Seconds are dropped for durations longer than an hour.
+func FormatDuration(Lang string, Duration time.Duration) string {
+	Duration = Duration.Round(time.Second)
+	if Duration < time.Second {
+		return "0" + T(Lang, "unit_second")
+	}
+	parts := []struct {
+		value int64
+		unit  string
+	}{
+		{int64(Duration / (24 * time.Hour)), "unit_day"},
+		{int64(Duration % (24 * time.Hour) / time.Hour), "unit_hour"},
+		{int64(Duration % time.Hour / time.Minute), "unit_minute"},
+		{int64(Duration % time.Minute / time.Second), "unit_second"},
+	}
+	if Duration >= time.Hour {
+		parts = parts[:3]
+	}
+	var text []string
+	for _, part := range parts {
+		if part.value != 0 {
+			text = append(text, fmt.Sprintf("%d%s", part.value, T(Lang, part.unit)))
+		}
+	}
+	return strings.Join(text, " ")
+}
@@ -0,0 +1,128 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	BoxColor       = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	TextColor      = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	TextBackground = color.RGBA{0x00, 0x00, 0x00, 0xa0}
+)
+
+// Annotation drawn on image. Box is skipped if empty, Lines are drawn in
+// top left corner.
+type Annotation struct {
+	Box   image.Rectangle
+	Label string
+	Lines []string
+}
+
+// RelativeRect converts Frigate relative box [x, y, width, height] to
+// rectangle of Bounds
+func RelativeRect(Bounds image.Rectangle, Box []float64) image.Rectangle {
+	if len(Box) != 4 {
+		return image.Rectangle{}
+	}
+	w, h := float64(Bounds.Dx()), float64(Bounds.Dy())
+	return image.Rect(
+		Bounds.Min.X+int(Box[0]*w), Bounds.Min.Y+int(Box[1]*h),
+		Bounds.Min.X+int((Box[0]+Box[2])*w), Bounds.Min.Y+int((Box[1]+Box[3])*h),
+	).Intersect(Bounds)
+}
+
+// TextScale returns text scale for image, so text is readable on full resolution
+func TextScale(Bounds image.Rectangle) int {
+	scale := Bounds.Dy() / 360
+	if scale < 1 {
+		return 1
+	}
+	return scale
+}
+
+// DrawLabel draws text on background with top left corner at X, Y and
+// returns size of drawn label
+func DrawLabel(Dst draw.Image, X int, Y int, Text string, Scale int) image.Point {
+	face := basicfont.Face7x13
+	padding := 2
+	w := font.MeasureString(face, Text).Ceil() + padding*2
+	h := face.Height + padding*2
+	label := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(label, label.Bounds(), &image.Uniform{TextBackground}, image.Point{}, draw.Src)
+	d := font.Drawer{
+		Dst:  label,
+		Src:  &image.Uniform{TextColor},
+		Face: face,
+		Dot:  fixed.P(padding, padding+face.Ascent),
+	}
+	d.DrawString(Text)
+	size := image.Pt(w*Scale, h*Scale)
+	draw.NearestNeighbor.Scale(Dst, image.Rectangle{Min: image.Pt(X, Y), Max: image.Pt(X, Y).Add(size)}, label, label.Bounds(), draw.Over, nil)
+	return size
+}
+
+// DrawRect draws rectangle border with Thickness
+func DrawRect(Dst draw.Image, Rect image.Rectangle, Color color.Color, Thickness int) {
+	src := &image.Uniform{Color}
+	for _, side := range []image.Rectangle{
+		image.Rect(Rect.Min.X, Rect.Min.Y, Rect.Max.X, Rect.Min.Y+Thickness),
+		image.Rect(Rect.Min.X, Rect.Max.Y-Thickness, Rect.Max.X, Rect.Max.Y),
+		image.Rect(Rect.Min.X, Rect.Min.Y, Rect.Min.X+Thickness, Rect.Max.Y),
+		image.Rect(Rect.Max.X-Thickness, Rect.Min.Y, Rect.Max.X, Rect.Max.Y),
+	} {
+		draw.Draw(Dst, side.Intersect(Dst.Bounds()), src, image.Point{}, draw.Src)
+	}
+}
+
+// Blur blurs region of image by downscaling and upscaling it back
+func Blur(Dst draw.Image, Rect image.Rectangle) {
+	Rect = Rect.Intersect(Dst.Bounds())
+	if Rect.Empty() {
+		return
+	}
+	w, h := Rect.Dx()/32, Rect.Dy()/32
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	// CatmullRom averages source pixels on downscale, so details are lost
+	draw.CatmullRom.Scale(small, small.Bounds(), Dst, Rect, draw.Src, nil)
+	draw.ApproxBiLinear.Scale(Dst, Rect, small, small.Bounds(), draw.Src, nil)
+}
+
+// Annotate returns copy of image with blurred privacy regions and annotation
+func Annotate(Img image.Image, Annotation Annotation, PrivacyRegions []image.Rectangle) *image.RGBA {
+	b := Img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, Img, b.Min, draw.Src)
+	for _, region := range PrivacyRegions {
+		Blur(dst, region)
+	}
+
+	scale := TextScale(b)
+	if !Annotation.Box.Empty() {
+		DrawRect(dst, Annotation.Box, BoxColor, 2*scale)
+		if Annotation.Label != "" {
+			// Label is above the box, inside the box if there is no space
+			y := Annotation.Box.Min.Y - (basicfont.Face7x13.Height+4)*scale
+			if y < b.Min.Y {
+				y = Annotation.Box.Min.Y
+			}
+			DrawLabel(dst, Annotation.Box.Min.X, y, Annotation.Label, scale)
+		}
+	}
+	y := b.Min.Y
+	for _, line := range Annotation.Lines {
+		y += DrawLabel(dst, b.Min.X, y, line, scale).Y
+	}
+	return dst
+}
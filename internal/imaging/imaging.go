@@ -0,0 +1,81 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// JPEG quality of rendered images
+var JPEGQuality int = 85
+
+// Decode JPEG or PNG bytes to image
+func Decode(Data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(Data))
+	return img, err
+}
+
+// EncodeJPEG encodes image to JPEG bytes
+func EncodeJPEG(Img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, Img, &jpeg.Options{Quality: JPEGQuality})
+	return buf.Bytes(), err
+}
+
+// Fit scales image into Width x Height box keeping aspect ratio
+func Fit(Img image.Image, Width int, Height int) image.Image {
+	b := Img.Bounds()
+	scale := math.Min(float64(Width)/float64(b.Dx()), float64(Height)/float64(b.Dy()))
+	w := int(math.Max(1, math.Round(float64(b.Dx())*scale)))
+	h := int(math.Max(1, math.Round(float64(b.Dy())*scale)))
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), Img, b, draw.Src, nil)
+	return dst
+}
+
+// Tile of grid image with optional label
+type Tile struct {
+	Image image.Image
+	Label string
+}
+
+// GridColumns returns count of columns for almost square grid of Count tiles
+func GridColumns(Count int) int {
+	return int(math.Ceil(math.Sqrt(float64(Count))))
+}
+
+// Grid tiles images into grid with Columns columns, every image is scaled
+// to fit Width x Height cell and centered, label is drawn in top left corner
+// of the cell.
+func Grid(Tiles []Tile, Columns int, Width int, Height int) *image.RGBA {
+	if Columns > len(Tiles) {
+		Columns = len(Tiles)
+	}
+	if Columns < 1 {
+		Columns = 1
+	}
+	rows := (len(Tiles) + Columns - 1) / Columns
+	dst := image.NewRGBA(image.Rect(0, 0, Columns*Width, rows*Height))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	for i, tile := range Tiles {
+		cellX := (i % Columns) * Width
+		cellY := (i / Columns) * Height
+		if tile.Image != nil {
+			scaled := Fit(tile.Image, Width, Height)
+			sb := scaled.Bounds()
+			x := cellX + (Width-sb.Dx())/2
+			y := cellY + (Height-sb.Dy())/2
+			draw.Draw(dst, image.Rect(x, y, x+sb.Dx(), y+sb.Dy()), scaled, sb.Min, draw.Src)
+		}
+		if tile.Label != "" {
+			DrawLabel(dst, cellX, cellY, tile.Label, 1)
+		}
+	}
+	return dst
+}
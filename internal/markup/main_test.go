@@ -0,0 +1,13 @@
+package markup
+
+import (
+	"os"
+	"testing"
+
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+func TestMain(m *testing.M) {
+	log.LogFunc()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,249 @@
+package markup
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+// MaxMessageLength is Telegram limit of message text in UTF-16 code units
+const MaxMessageLength = 4096
+
+// ParseMode of all formatted messages. HTML needs escaping of `<`, `>` and
+// `&` only, so static text of messages and templates stays readable.
+const ParseMode = tgbotapi.ModeHTML
+
+var (
+	escaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrQuote = strings.NewReplacer("\"", "&quot;")
+	linkTag   = regexp.MustCompile(`<a href="([^"]*)">(.*?)</a>`)
+	anyTag    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Escape escapes dynamic text, e.g. camera, label or zone name
+func Escape(Text string) string {
+	return escaper.Replace(Text)
+}
+
+// Bold returns escaped text in bold
+func Bold(Text string) string {
+	return "<b>" + Escape(Text) + "</b>"
+}
+
+// Code returns escaped text in monospace
+func Code(Text string) string {
+	return "<code>" + Escape(Text) + "</code>"
+}
+
+// CodeList returns escaped items in monospace separated by `, `
+func CodeList(Items []string) string {
+	codes := make([]string, 0, len(Items))
+	for _, item := range Items {
+		codes = append(codes, Code(item))
+	}
+	return strings.Join(codes, ", ")
+}
+
+// Link returns link with escaped text and URL
+func Link(Text string, URL string) string {
+	return `<a href="` + attrQuote.Replace(Escape(URL)) + `">` + Escape(Text) + "</a>"
+}
+
+// Plain converts formatted text to plain text, links are kept as `text (URL)`
+func Plain(Text string) string {
+	Text = linkTag.ReplaceAllString(Text, "$2 ($1)")
+	return html.UnescapeString(anyTag.ReplaceAllString(Text, ""))
+}
+
+// textLength returns length of text as Telegram counts it
+func textLength(Text string) int {
+	return len(utf16.Encode([]rune(Text)))
+}
+
+// Split splits text by lines into parts not longer than Limit, so tags
+// stay closed. Line longer than Limit is cut.
+func Split(Text string, Limit int) []string {
+	var parts []string
+	part := ""
+	add := func(Line string) {
+		if part != "" && textLength(part)+textLength(Line) > Limit {
+			parts = append(parts, strings.TrimRight(part, "\n"))
+			part = ""
+		}
+		part += Line
+	}
+	for _, line := range strings.SplitAfter(Text, "\n") {
+		for textLength(line) > Limit {
+			length, cut := 0, 0
+			for i, r := range line {
+				length += utf16.RuneLen(r)
+				if length > Limit {
+					cut = i
+					break
+				}
+			}
+			if cut == 0 {
+				_, cut = utf8.DecodeRuneInString(line)
+			}
+			add(line[:cut])
+			line = line[cut:]
+		}
+		add(line)
+	}
+	if strings.TrimSpace(part) != "" {
+		parts = append(parts, strings.TrimRight(part, "\n"))
+	}
+	return parts
+}
+
+// IsParseError checks whether Telegram rejected message formatting
+func IsParseError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "can't parse entities")
+}
+
+// streamed checks whether file is read from stream, it can't be uploaded twice
+func streamed(File tgbotapi.RequestFileData) bool {
+	switch File.(type) {
+	case tgbotapi.FileReader, *tgbotapi.FileReader:
+		return true
+	}
+	return false
+}
+
+// plain returns copy of message without formatting, false if message has
+// no formatted text or has file read from stream
+func plain(Chattable tgbotapi.Chattable) (tgbotapi.Chattable, bool) {
+	switch c := Chattable.(type) {
+	case tgbotapi.MessageConfig:
+		if c.ParseMode == "" {
+			return c, false
+		}
+		c.Text, c.ParseMode = Plain(c.Text), ""
+		return c, true
+	case tgbotapi.EditMessageTextConfig:
+		if c.ParseMode == "" {
+			return c, false
+		}
+		c.Text, c.ParseMode = Plain(c.Text), ""
+		return c, true
+	case tgbotapi.EditMessageCaptionConfig:
+		if c.ParseMode == "" {
+			return c, false
+		}
+		c.Caption, c.ParseMode = Plain(c.Caption), ""
+		return c, true
+	case tgbotapi.PhotoConfig:
+		if c.ParseMode == "" || streamed(c.File) {
+			return c, false
+		}
+		c.Caption, c.ParseMode = Plain(c.Caption), ""
+		return c, true
+	case tgbotapi.VideoConfig:
+		if c.ParseMode == "" || streamed(c.File) {
+			return c, false
+		}
+		c.Caption, c.ParseMode = Plain(c.Caption), ""
+		return c, true
+	case tgbotapi.AnimationConfig:
+		if c.ParseMode == "" || streamed(c.File) {
+			return c, false
+		}
+		c.Caption, c.ParseMode = Plain(c.Caption), ""
+		return c, true
+	case tgbotapi.MediaGroupConfig:
+		changed := false
+		media := make([]interface{}, len(c.Media))
+		for i, item := range c.Media {
+			switch m := item.(type) {
+			case tgbotapi.InputMediaPhoto:
+				if streamed(m.Media) {
+					return Chattable, false
+				}
+				if m.ParseMode != "" {
+					m.Caption, m.ParseMode, changed = Plain(m.Caption), "", true
+				}
+				item = m
+			case tgbotapi.InputMediaVideo:
+				if streamed(m.Media) {
+					return Chattable, false
+				}
+				if m.ParseMode != "" {
+					m.Caption, m.ParseMode, changed = Plain(m.Caption), "", true
+				}
+				item = m
+			}
+			media[i] = item
+		}
+		c.Media = media
+		return c, changed
+	}
+	return Chattable, false
+}
+
+// Send sends message, it is resent as plain text if Telegram can't parse
+// formatting. Files streamed by reader can't be read twice, so such message
+// isn't resent.
+func Send(bot *tgbotapi.BotAPI, Chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	message, err := bot.Send(Chattable)
+	if !IsParseError(err) {
+		return message, err
+	}
+	if fallback, ok := plain(Chattable); ok {
+		log.Warn.Println("Message is sent as plain text: " + err.Error())
+		return bot.Send(fallback)
+	}
+	return message, err
+}
+
+// Request is Send for requests without message in response, e.g. edits
+func Request(bot *tgbotapi.BotAPI, Chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	resp, err := bot.Request(Chattable)
+	if !IsParseError(err) {
+		return resp, err
+	}
+	if fallback, ok := plain(Chattable); ok {
+		log.Warn.Println("Message is sent as plain text: " + err.Error())
+		return bot.Request(fallback)
+	}
+	return resp, err
+}
+
+// SendLong sends text longer than Telegram limit as several messages, reply
+// markup is attached to the last one
+func SendLong(bot *tgbotapi.BotAPI, Config tgbotapi.MessageConfig) ([]tgbotapi.Message, error) {
+	var messages []tgbotapi.Message
+	parts := Split(Config.Text, MaxMessageLength)
+	replyMarkup := Config.ReplyMarkup
+	for i, part := range parts {
+		Config.Text = part
+		Config.ReplyMarkup = nil
+		if i == len(parts)-1 {
+			Config.ReplyMarkup = replyMarkup
+		}
+		message, err := Send(bot, Config)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// SendMediaGroup sends media group, captions are resent as plain text if
+// Telegram can't parse formatting
+func SendMediaGroup(bot *tgbotapi.BotAPI, Config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	messages, err := bot.SendMediaGroup(Config)
+	if !IsParseError(err) {
+		return messages, err
+	}
+	if fallback, ok := plain(Config); ok {
+		log.Warn.Println("Media group is sent as plain text: " + err.Error())
+		return bot.SendMediaGroup(fallback.(tgbotapi.MediaGroupConfig))
+	}
+	return messages, err
+}
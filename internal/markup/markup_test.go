@@ -0,0 +1,84 @@
+package markup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestEscape(t *testing.T) {
+	if got, want := Escape(`<Tom & "Jerry">`), `&lt;Tom &amp; "Jerry"&gt;`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := Link("a<b", `https://x/?a=1&b="2"`), `<a href="https://x/?a=1&amp;b=&quot;2&quot;">a&lt;b</a>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPlain(t *testing.T) {
+	text := Bold("Event") + "\n" + Code("a<b") + " " + Link("clip", "https://x/?a=1&b=2")
+	if got, want := Plain(text), "Event\na<b clip (https://x/?a=1&b=2)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := Bold("first") + "\n" + Bold("second") + "\n" + "third\n"
+	got := Split(text, 20)
+	want := []string{"<b>first</b>", "<b>second</b>\nthird"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := Split("short", MaxMessageLength); !reflect.DeepEqual(got, []string{"short"}) {
+		t.Errorf("got %q, want text as is", got)
+	}
+	// Emoji is two UTF-16 code units
+	got = Split(strings.Repeat("😀", 5), 4)
+	want = []string{"😀😀", "😀😀", "😀"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want long line cut by %q", got, want)
+	}
+}
+
+func TestPlainFallback(t *testing.T) {
+	bytesFile := tgbotapi.FileBytes{Name: "a.jpg", Bytes: []byte{1}}
+	readerFile := tgbotapi.FileReader{Name: "a.mp4", Reader: strings.NewReader("clip")}
+
+	photo := tgbotapi.NewPhoto(1, bytesFile)
+	photo.Caption, photo.ParseMode = Bold("Event"), ParseMode
+	fallback, ok := plain(photo)
+	if !ok || fallback.(tgbotapi.PhotoConfig).Caption != "Event" || fallback.(tgbotapi.PhotoConfig).ParseMode != "" {
+		t.Errorf("got %+v, %v, want photo with plain caption", fallback, ok)
+	}
+
+	message := tgbotapi.NewMessage(1, "text")
+	if _, ok := plain(message); ok {
+		t.Error("got fallback for message without formatting")
+	}
+
+	// Stream is already read by the first request
+	video := tgbotapi.NewVideo(1, readerFile)
+	video.Caption, video.ParseMode = Bold("Event"), ParseMode
+	if _, ok := plain(video); ok {
+		t.Error("got fallback for video read from stream")
+	}
+	animation := tgbotapi.NewAnimation(1, &readerFile)
+	animation.Caption, animation.ParseMode = Bold("Event"), ParseMode
+	if _, ok := plain(animation); ok {
+		t.Error("got fallback for animation read from stream")
+	}
+
+	thumbnail := tgbotapi.NewInputMediaPhoto(bytesFile)
+	thumbnail.Caption, thumbnail.ParseMode = Bold("Event"), ParseMode
+	group := tgbotapi.NewMediaGroup(1, []interface{}{thumbnail, tgbotapi.NewInputMediaVideo(bytesFile)})
+	fallback, ok = plain(group)
+	if !ok || fallback.(tgbotapi.MediaGroupConfig).Media[0].(tgbotapi.InputMediaPhoto).Caption != "Event" {
+		t.Errorf("got %+v, %v, want media group with plain caption", fallback, ok)
+	}
+	group = tgbotapi.NewMediaGroup(1, []interface{}{thumbnail, tgbotapi.NewInputMediaVideo(readerFile)})
+	if _, ok := plain(group); ok {
+		t.Error("got fallback for media group with clip read from stream")
+	}
+}
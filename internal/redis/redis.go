@@ -2,6 +2,10 @@ package redis
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/oldtyt/frigate-telegram/internal/config"
@@ -12,6 +16,7 @@ import (
 var (
 	RedisKeyStateSendEvent string          = "FrigateTelegramStoptSendEventMessage"
 	RedisKeyStateMuteEvent string          = "FrigateTelegramStoptSendEventMessage"
+	RedisKeyHistory        string          = "FrigateTelegramHistory"
 	ctx                    context.Context = context.Background()
 	conf                   *config.Config  = config.New()
 )
@@ -89,6 +94,15 @@ func AddNewEvent(EventID string, State string, RedisTTL time.Duration) {
 	}
 }
 
+// Check event was already processed
+func ExistsEvent(EventID string) bool {
+	event, err := rdb.Exists(ctx, EventID).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return event != 0
+}
+
 func CheckEvent(EventID string) bool {
 	event, err := rdb.Exists(ctx, EventID).Result()
 	if err != nil {
@@ -112,3 +126,288 @@ func CheckEvent(EventID string) bool {
 	}
 	return false
 }
+
+// Save zones where the event object is located now and return zones saved
+// before in transaction, so concurrent pollers don't see the same change.
+// Second value is false if the event was not tracked yet.
+func SwapEventZones(EventID string, Zones []string, RedisTTL time.Duration) ([]string, bool) {
+	key := "Zones_" + EventID
+	for {
+		var previous []string
+		tracked := false
+		err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+			val, err := tx.Get(ctx, key).Result()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+			if err == nil {
+				tracked = true
+				previous = []string{}
+				if val != "" {
+					previous = strings.Split(val, ",")
+				}
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, strings.Join(Zones, ","), RedisTTL)
+				return nil
+			})
+			return err
+		}, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			log.Error.Fatalln(err)
+		}
+		return previous, tracked
+	}
+}
+
+// Set key if it doesn't exist, returns false if key already exists
+func SetOnce(Key string, RedisTTL time.Duration) bool {
+	ok, err := rdb.SetNX(ctx, Key, 1, RedisTTL).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return ok
+}
+
+// Get time of the last heartbeat, second value is false if bot never run
+func GetHeartbeat() (int64, bool) {
+	val, err := rdb.Get(ctx, "FrigateTelegramHeartbeat").Int64()
+	if err == redis.Nil {
+		return 0, false
+	}
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return val, true
+}
+
+// Save current time as heartbeat
+func SetHeartbeat() {
+	err := rdb.Set(ctx, "FrigateTelegramHeartbeat", time.Now().Unix(), 0).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+}
+
+// Get start time of the last seen event, second value is false if cursor not saved
+func GetEventsCursor(Key string) (float64, bool) {
+	val, err := rdb.Get(ctx, "FrigateTelegramCursor_"+Key).Float64()
+	if err == redis.Nil {
+		return 0, false
+	}
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return val, true
+}
+
+// Save start time of the last seen event
+func SetEventsCursor(Key string, Cursor float64) {
+	err := rdb.Set(ctx, "FrigateTelegramCursor_"+Key, Cursor, 0).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+}
+
+// Add ID of event in progress to set Key, TTL of set is refreshed
+func AddInProgress(Key string, EventID string, RedisTTL time.Duration) {
+	key := "FrigateTelegramInProgress_" + Key
+	err := rdb.SAdd(ctx, key, EventID).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	err = rdb.Expire(ctx, key, RedisTTL).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+}
+
+// Get IDs of events in progress of set Key
+func GetInProgress(Key string) []string {
+	val, err := rdb.SMembers(ctx, "FrigateTelegramInProgress_"+Key).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return val
+}
+
+// Remove ID of finished event from set Key
+func RemoveInProgress(Key string, EventID string) {
+	err := rdb.SRem(ctx, "FrigateTelegramInProgress_"+Key, EventID).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+}
+
+// Get fields of event state hash, empty map if state not exists
+func GetEventState(Key string) map[string]string {
+	val, err := rdb.HGetAll(ctx, Key).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return val
+}
+
+// Set fields of event state hash and refresh TTL, zero TTL keeps current expiration
+func SetEventState(Key string, Values map[string]string, RedisTTL time.Duration) {
+	err := rdb.HSet(ctx, Key, Values).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	if RedisTTL == 0 {
+		return
+	}
+	err = rdb.Expire(ctx, Key, RedisTTL).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+}
+
+// Update event state hash in transaction. Update receives current fields and
+// returns fields to set, nil keeps state as is. Update is called again if
+// state is changed concurrently. Returns state after update.
+func UpdateEventState(Key string, Update func(map[string]string) map[string]string, RedisTTL time.Duration) map[string]string {
+	var state map[string]string
+	for {
+		err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := tx.HGetAll(ctx, Key).Result()
+			if err != nil {
+				return err
+			}
+			values := Update(current)
+			state = current
+			if values == nil {
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, Key, values)
+				pipe.Expire(ctx, Key, RedisTTL)
+				return nil
+			})
+			if err == nil {
+				for field, value := range values {
+					state[field] = value
+				}
+			}
+			return err
+		}, Key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			log.Error.Fatalln(err)
+		}
+		return state
+	}
+}
+
+// Increment counter field of event state hash, TTL is set if hash has no expiration
+func IncrEventState(Key string, Field string, RedisTTL time.Duration) int64 {
+	val, err := rdb.HIncrBy(ctx, Key, Field, 1).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	ttl, err := rdb.TTL(ctx, Key).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	if ttl < 0 {
+		rdb.Expire(ctx, Key, RedisTTL)
+	}
+	return val
+}
+
+// Delete event state hash
+func DelEventState(Key string) {
+	err := rdb.Del(ctx, Key).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+}
+
+// Save history record and index it by event start time, records older than TTL are removed from index
+func AddHistory(Key string, Record string, Time float64, RedisTTL time.Duration) {
+	err := rdb.Set(ctx, "History_"+Key, Record, RedisTTL).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	err = rdb.ZAdd(ctx, RedisKeyHistory, redis.Z{Score: Time, Member: Key}).Err()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	expired := strconv.FormatFloat(float64(time.Now().Add(-RedisTTL).Unix()), 'f', -1, 64)
+	rdb.ZRemRangeByScore(ctx, RedisKeyHistory, "-inf", "("+expired)
+}
+
+// Get history record, second value is false if record not exists
+func GetHistory(Key string) (string, bool) {
+	val, err := rdb.Get(ctx, "History_"+Key).Result()
+	if err == redis.Nil {
+		return "", false
+	}
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return val, true
+}
+
+// Get keys of history records started after Since, newest first
+func GetHistoryKeys(Since float64) []string {
+	keys, err := rdb.ZRevRangeByScore(ctx, RedisKeyHistory, &redis.ZRangeBy{
+		Min: strconv.FormatFloat(Since, 'f', -1, 64),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		log.Error.Fatalln(err)
+	}
+	return keys
+}
+
+// Get language of chat, second value is false if language not set
+func GetChatLanguage(ChatID int64) (string, bool) {
+	val, err := rdb.Get(ctx, "FrigateTelegramLanguage_"+strconv.FormatInt(ChatID, 10)).Result()
+	if err == redis.Nil {
+		return "", false
+	}
+	if err != nil {
+		log.Error.Println(err)
+		return "", false
+	}
+	return val, true
+}
+
+// Save language of chat
+func SetChatLanguage(ChatID int64, Lang string) bool {
+	err := rdb.Set(ctx, "FrigateTelegramLanguage_"+strconv.FormatInt(ChatID, 10), Lang, 0).Err()
+	if err != nil {
+		log.Error.Println(err)
+		return false
+	}
+	return true
+}
+
+// Save callback data of inline button, returns short key of data
+func SetCallbackData(Data string, RedisTTL time.Duration) string {
+	hash := sha1.Sum([]byte(Data))
+	key := hex.EncodeToString(hash[:8])
+	err := rdb.Set(ctx, "FrigateTelegramCallback_"+key, Data, RedisTTL).Err()
+	if err != nil {
+		log.Error.Println(err)
+	}
+	return key
+}
+
+// Get callback data by short key, second value is false if data expired
+func GetCallbackData(Key string) (string, bool) {
+	val, err := rdb.Get(ctx, "FrigateTelegramCallback_"+Key).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Error.Println(err)
+		}
+		return "", false
+	}
+	return val, true
+}
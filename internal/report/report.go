@@ -0,0 +1,359 @@
+package report
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/chart"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/frigate"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+	"github.com/oldtyt/frigate-telegram/internal/redis"
+)
+
+const (
+	PeriodToday     = "today"
+	PeriodYesterday = "yesterday"
+	PeriodDay       = "day"
+	PeriodWeek      = "week"
+)
+
+// Count of longest and top scoring events in report
+var TopEventsLimit int = 3
+
+type ReportEvent struct {
+	Instance string  `json:"instance,omitempty"`
+	ID       string  `json:"id"`
+	Camera   string  `json:"camera"`
+	Label    string  `json:"label"`
+	Score    float64 `json:"score"`
+	Start    int64   `json:"start_time"`
+	Duration float64 `json:"duration"`
+	URL      string  `json:"url"`
+}
+
+type Report struct {
+	Period       string                    `json:"period"`
+	From         time.Time                 `json:"from"`
+	To           time.Time                 `json:"to"`
+	Total        int                       `json:"total"`
+	Cameras      map[string]int            `json:"cameras"`
+	Labels       map[string]int            `json:"labels"`
+	CameraLabels map[string]map[string]int `json:"camera_labels"`
+	Hours        [24]int                   `json:"hours"`
+	Heatmap      [7][24]int                `json:"heatmap"`
+	Longest      []ReportEvent             `json:"longest"`
+	TopScore     []ReportEvent             `json:"top_score"`
+	// Events of period, used for rendering and not returned by API
+	Events frigate.EventsStruct `json:"-"`
+}
+
+// PeriodRange returns time range of report period
+func PeriodRange(Period string, Now time.Time) (time.Time, time.Time, error) {
+	midnight := time.Date(Now.Year(), Now.Month(), Now.Day(), 0, 0, 0, 0, Now.Location())
+	switch Period {
+	case PeriodToday:
+		return midnight, Now, nil
+	case PeriodYesterday:
+		return midnight.AddDate(0, 0, -1), midnight, nil
+	case PeriodDay:
+		return Now.Add(-24 * time.Hour), Now, nil
+	case PeriodWeek:
+		return Now.AddDate(0, 0, -7), Now, nil
+	}
+	return Now, Now, i18n.NewError("unknown_period", Period)
+}
+
+// Build collects events of period from all Frigate instances
+func Build(Period string) (Report, error) {
+	conf := config.New()
+	from, to, err := PeriodRange(Period, i18n.LocalTime(time.Now()))
+	if err != nil {
+		return Report{}, err
+	}
+	r := Report{
+		Period:       Period,
+		From:         from,
+		To:           to,
+		Cameras:      map[string]int{},
+		Labels:       map[string]int{},
+		CameraLabels: map[string]map[string]int{},
+	}
+	for _, instance := range conf.FrigateInstances {
+		events, err := frigate.GetEventsRange(instance, float64(from.Unix()), float64(to.Unix()), false)
+		if err != nil {
+			return r, err
+		}
+		for _, event := range events {
+			if frigate.SkipEventReason(event) != "" {
+				continue
+			}
+			r.Events = append(r.Events, event)
+		}
+	}
+
+	for _, event := range r.Events {
+		camera := CameraName(event)
+		r.Total++
+		r.Cameras[camera]++
+		r.Labels[event.Label]++
+		if r.CameraLabels[camera] == nil {
+			r.CameraLabels[camera] = map[string]int{}
+		}
+		r.CameraLabels[camera][event.Label]++
+		start := time.Unix(int64(event.StartTime), 0).In(from.Location())
+		r.Hours[start.Hour()]++
+		// Heatmap week starts from Monday
+		r.Heatmap[(int(start.Weekday())+6)%7][start.Hour()]++
+	}
+
+	longest := append(frigate.EventsStruct{}, r.Events...)
+	sort.SliceStable(longest, func(i, j int) bool {
+		return EventDuration(longest[i], to) > EventDuration(longest[j], to)
+	})
+	for i := 0; i < len(longest) && i < TopEventsLimit; i++ {
+		r.Longest = append(r.Longest, NewReportEvent(longest[i], to))
+	}
+
+	top := append(frigate.EventsStruct{}, r.Events...)
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Data.TopScore > top[j].Data.TopScore
+	})
+	for i := 0; i < len(top) && i < TopEventsLimit; i++ {
+		r.TopScore = append(r.TopScore, NewReportEvent(top[i], to))
+	}
+	return r, nil
+}
+
+// CameraName returns camera name prefixed with instance name
+func CameraName(FrigateEvent frigate.EventStruct) string {
+	if FrigateEvent.Instance == "" {
+		return FrigateEvent.Camera
+	}
+	return FrigateEvent.Instance + "/" + FrigateEvent.Camera
+}
+
+// EventDuration returns duration of event, event in progress lasts till To
+func EventDuration(FrigateEvent frigate.EventStruct, To time.Time) float64 {
+	end := FrigateEvent.EndTime
+	if end == 0 {
+		end = float64(To.Unix())
+	}
+	return end - FrigateEvent.StartTime
+}
+
+func NewReportEvent(FrigateEvent frigate.EventStruct, To time.Time) ReportEvent {
+	instance := frigate.GetInstance(FrigateEvent.Instance)
+	return ReportEvent{
+		Instance: FrigateEvent.Instance,
+		ID:       FrigateEvent.ID,
+		Camera:   FrigateEvent.Camera,
+		Label:    FrigateEvent.Label,
+		Score:    FrigateEvent.Data.TopScore,
+		Start:    int64(FrigateEvent.StartTime),
+		Duration: EventDuration(FrigateEvent, To),
+		URL:      instance.ExternalURL + "/api/events/" + FrigateEvent.ID + "/clip.mp4",
+	}
+}
+
+// BusiestHours returns up to Limit hours with the most events
+func BusiestHours(r Report, Limit int) []int {
+	var hours []int
+	for hour, count := range r.Hours {
+		if count != 0 {
+			hours = append(hours, hour)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return r.Hours[hours[i]] > r.Hours[hours[j]]
+	})
+	if len(hours) > Limit {
+		hours = hours[:Limit]
+	}
+	return hours
+}
+
+// Format returns text of report for telegram in Lang
+func Format(r Report, Lang string) string {
+	conf := config.New()
+	text := markup.Bold(i18n.T(Lang, "report_for", r.Period)) + "\n"
+	text += "┣<b>" + i18n.T(Lang, "from") + "</b>\n┗ " + markup.Code(i18n.FormatTime(r.From)) + "\n"
+	text += "┣<b>" + i18n.T(Lang, "to") + "</b>\n┗ " + markup.Code(i18n.FormatTime(r.To)) + "\n"
+	text += "┣<b>" + i18n.T(Lang, "total_events") + "</b>\n┗ " + markup.Code(strconv.Itoa(r.Total)) + "\n"
+	if r.Total == 0 {
+		return text
+	}
+
+	text += "<b>" + i18n.T(Lang, "events_per_camera") + "</b>\n"
+	text += frigate.FormatEventCounts(r.CameraLabels)
+
+	text += "<b>" + i18n.T(Lang, "busiest_hours") + "</b>\n"
+	var hours []string
+	for _, hour := range BusiestHours(r, 3) {
+		hours = append(hours, fmt.Sprintf("<code>%02d:00</code> %d", hour, r.Hours[hour]))
+	}
+	text += "┗ " + strings.Join(hours, ", ") + "\n"
+
+	text += "<b>" + i18n.T(Lang, "longest_events") + "</b>\n"
+	text += FormatReportEvents(r.Longest, Lang, func(e ReportEvent) string {
+		return i18n.FormatDuration(Lang, time.Duration(e.Duration)*time.Second)
+	})
+	text += "<b>" + i18n.T(Lang, "top_score") + "</b>\n"
+	text += FormatReportEvents(r.TopScore, Lang, func(e ReportEvent) string {
+		return fmt.Sprintf("%.1f%%", e.Score*100)
+	})
+
+	text += "<b>" + i18n.T(Lang, "urls") + "</b>\n"
+	for i, instance := range conf.FrigateInstances {
+		prefix := "┣"
+		if i == len(conf.FrigateInstances)-1 {
+			prefix = "┗"
+		}
+		name := i18n.T(Lang, "general")
+		if instance.Name != "" {
+			name = instance.Name
+		}
+		text += prefix + markup.Link(name, instance.ExternalURL+"/events") + "\n"
+	}
+	return text
+}
+
+func FormatReportEvents(Events []ReportEvent, Lang string, Value func(ReportEvent) string) string {
+	text := ""
+	for i, e := range Events {
+		prefix := "┣"
+		if i == len(Events)-1 {
+			prefix = "┗"
+		}
+		text += prefix + "#" + frigate.NormalizeTagText(e.Camera) + " #" + frigate.NormalizeTagText(e.Label)
+		text += " " + markup.Code(Value(e)) + " " + markup.Link(i18n.T(Lang, "clip_link"), e.URL) + "\n"
+	}
+	return text
+}
+
+// Send builds report and sends it with top scoring snapshots to chat
+func Send(bot *tgbotapi.BotAPI, ChatID int64, Period string) error {
+	r, err := Build(Period)
+	if err != nil {
+		return err
+	}
+
+	lang := i18n.Language(ChatID)
+	msg := tgbotapi.NewMessage(ChatID, Format(r, lang))
+	msg.ParseMode = markup.ParseMode
+	msg.DisableWebPagePreview = true
+	if _, err := markup.SendLong(bot, msg); err != nil {
+		return err
+	}
+
+	if config.New().ReportCharts && r.Total != 0 {
+		charts, err := Charts(r, lang)
+		if err != nil {
+			log.Error.Println("Error rendering report charts: " + err.Error())
+		} else if _, err := markup.SendMediaGroup(bot, tgbotapi.NewMediaGroup(ChatID, charts)); err != nil {
+			return err
+		}
+	}
+
+	var medias []interface{}
+	for _, e := range r.TopScore {
+		instance := frigate.GetInstance(e.Instance)
+		data, err := frigate.GetFrigateBytes(instance, instance.URL+"/api/events/"+e.ID+"/snapshot.jpg")
+		if err == nil {
+			data, err = frigate.BlurPrivacy(e.Instance, e.Camera, data, true)
+		}
+		if err != nil {
+			log.Warn.Println("Error getting snapshot for report: " + err.Error())
+			continue
+		}
+		photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: e.ID + ".jpg", Bytes: data})
+		photo.Caption = fmt.Sprintf("%s %s %.1f%%", e.Camera, e.Label, e.Score*100)
+		medias = append(medias, photo)
+	}
+	if len(medias) != 0 {
+		if _, err := markup.SendMediaGroup(bot, tgbotapi.NewMediaGroup(ChatID, medias)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Charts renders report charts as media group photos with captions and
+// weekdays in Lang
+func Charts(r Report, Lang string) ([]interface{}, error) {
+	var cameras []string
+	for camera := range r.Cameras {
+		cameras = append(cameras, camera)
+	}
+	sort.Strings(cameras)
+	var counts []int
+	for _, camera := range cameras {
+		counts = append(counts, r.Cameras[camera])
+	}
+
+	var weekdays []string
+	for _, day := range []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"} {
+		weekdays = append(weekdays, i18n.T(Lang, "weekday_"+day))
+	}
+
+	images := map[string]image.Image{
+		"hours.png":   chart.HourHistogram(r.Hours),
+		"cameras.png": chart.BarChart(cameras, counts),
+		"heatmap.png": chart.Heatmap(r.Heatmap, weekdays),
+	}
+	captions := map[string]string{
+		"hours.png":   i18n.T(Lang, "chart_hours"),
+		"cameras.png": i18n.T(Lang, "events_per_camera"),
+		"heatmap.png": i18n.T(Lang, "chart_heatmap"),
+	}
+	var medias []interface{}
+	for _, name := range []string{"hours.png", "cameras.png", "heatmap.png"} {
+		data, err := chart.EncodePNG(images[name])
+		if err != nil {
+			return nil, err
+		}
+		photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: name, Bytes: data})
+		photo.Caption = captions[name]
+		medias = append(medias, photo)
+	}
+	return medias, nil
+}
+
+// RunScheduler sends report by REPORT_SCHEDULE at REPORT_TIME
+func RunScheduler(bot *tgbotapi.BotAPI) {
+	conf := config.New()
+	if conf.ReportSchedule == "None" {
+		return
+	}
+	period := PeriodDay
+	if conf.ReportSchedule == "weekly" {
+		period = PeriodWeek
+	}
+	chatID := conf.ReportChatID
+	if chatID == 0 {
+		chatID = conf.TelegramChatID
+	}
+	log.Info.Println("Starting " + conf.ReportSchedule + " report scheduler at " + conf.ReportTime)
+
+	for {
+		now := i18n.LocalTime(time.Now())
+		if now.Format("15:04") == conf.ReportTime &&
+			(period == PeriodDay || strings.EqualFold(now.Weekday().String(), conf.ReportWeekday)) {
+			// Remember sent report, so restart at the same minute doesn't repeat it
+			key := "FrigateTelegramReport_" + period + "_" + now.Format("2006-01-02")
+			if redis.SetOnce(key, 48*time.Hour) {
+				if err := Send(bot, chatID, period); err != nil {
+					log.Error.Println("Error sending scheduled report: " + err.Error())
+				}
+			}
+		}
+		time.Sleep(time.Duration(60-now.Second()) * time.Second)
+	}
+}
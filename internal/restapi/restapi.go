@@ -4,12 +4,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/oldtyt/frigate-telegram/docs"
 	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/frigate"
+	"github.com/oldtyt/frigate-telegram/internal/history"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
 	"github.com/oldtyt/frigate-telegram/internal/log"
 	"github.com/oldtyt/frigate-telegram/internal/redis"
+	"github.com/oldtyt/frigate-telegram/internal/report"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -17,10 +23,12 @@ import (
 var redisErrorText string = "Error setting value, check logs."
 
 type ResponseApi struct {
-	IsError      bool   `json:"error"`
-	Message      string `json:"message"`
-	SendingEvent string `json:"send_event,omitempty"`
-	MuteEvent    string `json:"mute_event,omitempty"`
+	IsError      bool             `json:"error"`
+	Message      string           `json:"message"`
+	SendingEvent string           `json:"send_event,omitempty"`
+	MuteEvent    string           `json:"mute_event,omitempty"`
+	Report       *report.Report   `json:"report,omitempty"`
+	Events       []history.Record `json:"events,omitempty"`
 }
 
 func serverDocs(apiPath string) {
@@ -185,6 +193,109 @@ func Status(c *gin.Context) {
 		MuteEvent:    strconv.FormatBool(redis.GetStateMuteEvent())})
 }
 
+// Report godoc
+// @Summary      Get report
+// @Description  Summary of events for period
+// @Tags         report
+// @Accept       json
+// @Produce      json
+// @Param        period  query  string  false  "today, yesterday, day or week"  default(today)
+// @Success      200
+// @Failure      502
+// @Router       /report [get]
+func Report(c *gin.Context) {
+	r, err := report.Build(c.DefaultQuery("period", report.PeriodToday))
+	if err != nil {
+		ReturnResponse(c, ResponseApi{
+			IsError: true,
+			Message: err.Error(),
+		})
+		return
+	}
+	ReturnResponse(c, ResponseApi{
+		IsError: false,
+		Message: "",
+		Report:  &r,
+	})
+}
+
+// Events godoc
+// @Summary      Get events history
+// @Description  Events processed by bot, newest first
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        instance  query  string  false  "Frigate instance"
+// @Param        camera    query  string  false  "Camera"
+// @Param        label     query  string  false  "Label"
+// @Param        status    query  string  false  "sent, skipped, collapsed, digest or backlog"
+// @Param        since     query  string  false  "Duration, e.g. 2h or 7d"  default(24h)
+// @Param        limit     query  int     false  "Max count of events"      default(100)
+// @Success      200
+// @Failure      502
+// @Router       /events [get]
+func Events(c *gin.Context) {
+	var args []string
+	for _, key := range []string{"instance", "camera", "label", "status", "since", "limit"} {
+		if value := c.Query(key); value != "" {
+			args = append(args, key+"="+value)
+		}
+	}
+	filter, err := history.ParseFilter(strings.Join(args, " "), 100)
+	if err != nil {
+		ReturnResponse(c, ResponseApi{
+			IsError: true,
+			Message: err.Error(),
+		})
+		return
+	}
+	ReturnResponse(c, ResponseApi{
+		IsError: false,
+		Message: "",
+		Events:  history.Query(filter),
+	})
+}
+
+// Clip godoc
+// @Summary      Get recording clip
+// @Description  Recording of camera for time range, e.g. from=18:30 or from=10 minutes ago
+// @Tags         recordings
+// @Produce      video/mp4
+// @Param        camera    query  string  true   "Camera, instance/camera for named instances"
+// @Param        from      query  string  true   "Start time: 18:30, 2024-05-01 18:30, 10 minutes ago or unix timestamp"
+// @Param        duration  query  string  true   "Duration, e.g. 90s, 5m or 5 minutes"
+// @Success      200
+// @Failure      502
+// @Router       /clip [get]
+func Clip(c *gin.Context) {
+	from, to, err := frigate.ParseClipRange(c.Query("from"), c.Query("duration"), i18n.LocalTime(time.Now()))
+	if err != nil {
+		ReturnResponse(c, ResponseApi{
+			IsError: true,
+			Message: err.Error(),
+		})
+		return
+	}
+	instance, camera, err := frigate.FindCamera(c.Query("camera"))
+	if err != nil {
+		ReturnResponse(c, ResponseApi{
+			IsError: true,
+			Message: err.Error(),
+		})
+		return
+	}
+	resp, err := frigate.OpenFrigateStream(instance, frigate.RecordingURL(instance.URL, camera, from, to))
+	if err != nil {
+		ReturnResponse(c, ResponseApi{
+			IsError: true,
+			Message: "Error getting recording: " + err.Error(),
+		})
+		return
+	}
+	defer resp.Body.Close()
+	c.DataFromReader(http.StatusOK, resp.ContentLength, "video/mp4", resp.Body, nil)
+}
+
 func RunServer(conf *config.Config) {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
@@ -197,6 +308,9 @@ func RunServer(conf *config.Config) {
 	r.GET(apiPath+"/mute", Mute)
 	r.GET(apiPath+"/unmute", Unmute)
 	r.GET(apiPath+"/status", Status)
+	r.GET(apiPath+"/report", Report)
+	r.GET(apiPath+"/events", Events)
+	r.GET(apiPath+"/clip", Clip)
 
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	log.Info.Println("Start Rest API on " + conf.RestAPIListenAddr)
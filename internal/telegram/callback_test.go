@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memoryCallbackData replaces Redis storage of callback data
+func memoryCallbackData(t *testing.T) map[string]string {
+	stored := map[string]string{}
+	store, load := storeCallbackData, loadCallbackData
+	storeCallbackData = func(Data string) string {
+		key := string(rune('a' + len(stored)))
+		stored[key] = Data
+		return key
+	}
+	loadCallbackData = func(Key string) (string, bool) {
+		data, ok := stored[Key]
+		return data, ok
+	}
+	t.Cleanup(func() { storeCallbackData, loadCallbackData = store, load })
+	return stored
+}
+
+func TestCallbackDataShort(t *testing.T) {
+	stored := memoryCallbackData(t)
+	data := callbackData(callbackEventOpen, "home", "1718000000.123456-abcdef")
+	if data != "evo|home|1718000000.123456-abcdef" {
+		t.Fatalf("got data %q, want plain data", data)
+	}
+	if len(stored) != 0 {
+		t.Fatalf("short data is stored: %v", stored)
+	}
+	got, err := parseCallbackData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{callbackEventOpen, "home", "1718000000.123456-abcdef"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCallbackDataLong(t *testing.T) {
+	memoryCallbackData(t)
+	params := url.Values{"bbox": {"1"}, "height": {"720"}, "quality": {"90"}}
+	args := []string{"summer_house/" + strings.Repeat("front_door_", 4), params.Encode()}
+	data := callbackData(callbackSnapshot, args...)
+	if len(data) > maxCallbackData {
+		t.Fatalf("got %d bytes of callback data, limit is %d", len(data), maxCallbackData)
+	}
+	got, err := parseCallbackData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]string{callbackSnapshot}, args...); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCallbackDataExpired(t *testing.T) {
+	memoryCallbackData(t)
+	if _, err := parseCallbackData(callbackRef + "|missing"); err == nil {
+		t.Fatal("got no error for expired reference")
+	}
+	if _, err := parseCallbackData(callbackRef); err == nil {
+		t.Fatal("got no error for reference without key")
+	}
+}
+
+func TestEventsQueryData(t *testing.T) {
+	memoryCallbackData(t)
+	for _, query := range []EventsQuery{
+		{Camera: "all", Label: "all", Limit: 5, Page: 2, Before: 1718000000},
+		{Camera: "summer_house/" + strings.Repeat("driveway_", 4), Label: "motorcycle", Limit: 10, Page: 12, Before: 1718000000},
+	} {
+		data := query.data()
+		if len(data) > maxCallbackData {
+			t.Errorf("got %d bytes of callback data for %v", len(data), query)
+			continue
+		}
+		fields, err := parseCallbackData(data)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		got, err := parseEventsData(fields)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got != query {
+			t.Errorf("got query %v, want %v", got, query)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package telegram
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/frigate"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+func onlineIcon(Online bool) string {
+	if Online {
+		return "🟢"
+	}
+	return "🔴"
+}
+
+func enabledText(Lang string, Enabled bool) string {
+	if Enabled {
+		return i18n.T(Lang, "on")
+	}
+	return i18n.T(Lang, "off")
+}
+
+// Cameras lists cameras of all instances with fps and online status
+func Cameras(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID != conf.TelegramChatID {
+		return false, msg
+	}
+	lang := i18n.Language(msg.BaseChat.ChatID)
+	text := "<b>" + i18n.T(lang, "cameras") + "</b>\n"
+	for _, instance := range conf.FrigateInstances {
+		frigateConfig, err := frigate.GetFrigateConfig(instance)
+		if err != nil {
+			log.Error.Println("Error getting Frigate config: " + err.Error())
+			text += "┗ " + i18n.T(lang, "unreachable", markup.Escape(instance.URL)) + "\n"
+			continue
+		}
+		stats, err := frigate.GetFrigateStats(instance)
+		if err != nil {
+			log.Warn.Println("Error getting Frigate stats: " + err.Error())
+		}
+		var cameras []string
+		for camera := range frigateConfig.Cameras {
+			cameras = append(cameras, camera)
+		}
+		sort.Strings(cameras)
+		for _, camera := range cameras {
+			cameraStats := stats.Cameras[camera]
+			text += fmt.Sprintf("%s %s\n┗ fps <code>%.1f</code> detect <code>%.1f</code> skipped <code>%.1f</code>\n",
+				onlineIcon(frigateConfig.Cameras[camera].Enabled && frigate.CameraOnline(cameraStats)),
+				markup.Code(frigate.CameraName(instance, camera)),
+				cameraStats.CameraFPS, cameraStats.DetectionFPS, cameraStats.SkippedFPS)
+		}
+	}
+	text += markup.Escape(i18n.T(lang, "help_camera") + ": /camera <name>")
+	msg.Text = text
+	msg.ParseMode = markup.ParseMode
+	return true, msg
+}
+
+// Camera sends camera details with latest snapshot
+func Camera(msg tgbotapi.MessageConfig, conf *config.Config, bot *tgbotapi.BotAPI, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID != conf.TelegramChatID {
+		return false, msg
+	}
+	lang := i18n.Language(msg.BaseChat.ChatID)
+	name := strings.TrimSpace(args)
+	if name == "" {
+		msg.Text = i18n.T(lang, "usage", "/camera <name>")
+		return true, msg
+	}
+	instance, camera, err := frigate.FindCamera(name)
+	if err != nil {
+		msg.Text = i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	frigateConfig, err := frigate.GetFrigateConfig(instance)
+	if err != nil {
+		msg.Text = i18n.T(lang, "error_frigate_config") + ": " + i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	cameraConfig, ok := frigateConfig.Cameras[camera]
+	if !ok {
+		msg.Text = i18n.T(lang, "unknown_camera", name)
+		return true, msg
+	}
+	stats, err := frigate.GetFrigateStats(instance)
+	if err != nil {
+		log.Warn.Println("Error getting Frigate stats: " + err.Error())
+	}
+	cameraStats := stats.Cameras[camera]
+	online := cameraConfig.Enabled && frigate.CameraOnline(cameraStats)
+	name = frigate.CameraName(instance, camera)
+
+	var zones []string
+	for zone := range cameraConfig.Zones {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	text := "#" + frigate.NormalizeTagText(name) + "\n"
+	status := i18n.T(lang, "offline")
+	if online {
+		status = i18n.T(lang, "online")
+	}
+	text += "┣<b>" + i18n.T(lang, "status") + "</b>\n┗ " + onlineIcon(online) + " " + status + "\n"
+	text += fmt.Sprintf("┣<b>FPS</b>\n┗ camera <code>%.1f</code> process <code>%.1f</code> detect <code>%.1f</code> skipped <code>%.1f</code>\n",
+		cameraStats.CameraFPS, cameraStats.ProcessFPS, cameraStats.DetectionFPS, cameraStats.SkippedFPS)
+	text += fmt.Sprintf("┣<b>%s</b>\n┗ <code>%s</code> %dx%d@%d\n", i18n.T(lang, "detect"),
+		enabledText(lang, cameraConfig.Detect.Enabled), cameraConfig.Detect.Width, cameraConfig.Detect.Height, cameraConfig.Detect.FPS)
+	text += "┣<b>" + i18n.T(lang, "record") + "</b>\n┗ " + markup.Code(enabledText(lang, cameraConfig.Record.Enabled)) + "\n"
+	text += "┣<b>" + i18n.T(lang, "snapshots") + "</b>\n┗ " + markup.Code(enabledText(lang, cameraConfig.Snapshots.Enabled)) + "\n"
+	if len(cameraConfig.Objects.Track) != 0 {
+		text += "┣<b>" + i18n.T(lang, "objects") + "</b>\n┗ " + markup.CodeList(cameraConfig.Objects.Track) + "\n"
+	}
+	if len(zones) != 0 {
+		text += "┣<b>" + i18n.T(lang, "zones") + "</b>\n┗ " + markup.CodeList(zones) + "\n"
+	}
+
+	data, err := frigate.GetLatestSnapshot(instance, camera, url.Values{})
+	if err != nil {
+		log.Warn.Println("Error getting snapshot of " + camera + ": " + err.Error())
+		msg.Text = text
+		msg.ParseMode = markup.ParseMode
+		return true, msg
+	}
+	photo := tgbotapi.NewPhoto(msg.BaseChat.ChatID, tgbotapi.FileBytes{Name: camera + ".jpg", Bytes: data})
+	photo.Caption = text
+	photo.ParseMode = markup.ParseMode
+	if _, err := markup.Send(bot, photo); err != nil {
+		log.Error.Println("Error sending camera details: " + err.Error())
+		msg.Text = i18n.T(lang, "error_send_camera") + ": " + i18n.ErrorText(lang, err)
+	}
+	return true, msg
+}
@@ -0,0 +1,132 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/frigate"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+// ParseClipArgs splits `<camera> <from> <duration>` arguments, duration is
+// the last one or two words, e.g. `5m` or `5 minutes`.
+func ParseClipArgs(Args string, Now time.Time) (string, time.Time, time.Time, error) {
+	fields := strings.Fields(Args)
+	if len(fields) < 3 {
+		return "", Now, Now, i18n.NewError("clip_usage")
+	}
+	camera, rest := fields[0], fields[1:]
+	if len(rest) > 2 {
+		if _, err := frigate.ParseDurationText(strings.Join(rest[len(rest)-2:], " ")); err == nil {
+			from, to, err := frigate.ParseClipRange(strings.Join(rest[:len(rest)-2], " "), strings.Join(rest[len(rest)-2:], " "), Now)
+			return camera, from, to, err
+		}
+	}
+	from, to, err := frigate.ParseClipRange(strings.Join(rest[:len(rest)-1], " "), rest[len(rest)-1], Now)
+	return camera, from, to, err
+}
+
+// Clip sends recording of camera for requested time range
+func Clip(msg tgbotapi.MessageConfig, conf *config.Config, bot *tgbotapi.BotAPI, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID != conf.TelegramChatID {
+		return false, msg
+	}
+	lang := i18n.Language(msg.BaseChat.ChatID)
+	name, from, to, err := ParseClipArgs(args, i18n.LocalTime(time.Now()))
+	if err != nil {
+		msg.Text = i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	instance, camera, err := frigate.FindCamera(name)
+	if err != nil {
+		msg.Text = i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	name = frigate.CameraName(instance, camera)
+	caption := fmt.Sprintf("#%s %s - %s", frigate.NormalizeTagText(name),
+		markup.Code(i18n.FormatTime(from)), markup.Code(i18n.LocalTime(to).Format("15:04:05")))
+	url := frigate.RecordingURL(instance.ExternalURL, camera, from, to)
+	// Recording of camera with privacy regions can't be blurred
+	if note := frigate.PrivateClipNote(instance.Name, camera, url); note != "" {
+		if err := sendClipNote(bot, msg.BaseChat.ChatID, 0, caption, note); err != nil {
+			log.Error.Println("Error sending clip: " + err.Error())
+			msg.Text = i18n.T(lang, "error_send_clip") + ": " + i18n.ErrorText(lang, err)
+			return true, msg
+		}
+		msg.Text = ""
+		return true, msg
+	}
+	if _, err := bot.Send(tgbotapi.NewChatAction(msg.BaseChat.ChatID, tgbotapi.ChatUploadVideo)); err != nil {
+		log.Warn.Println(err.Error())
+	}
+	clip, err := frigate.OpenRecordingClip(instance, camera, from, to)
+	if err != nil {
+		msg.Text = i18n.T(lang, "error_recording") + ": " + i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	defer clip.Close()
+	if err := sendClip(bot, msg.BaseChat.ChatID, 0, clip, url, "recording of "+name, caption); err != nil {
+		log.Error.Println("Error sending clip: " + err.Error())
+		msg.Text = i18n.T(lang, "error_send_clip") + ": " + i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	msg.Text = ""
+	return true, msg
+}
+
+// sendClip sends opened clip as video. Clip larger than Telegram limit is
+// fitted by CLIP_OVERSIZE_STRATEGY, link strategy sends URL.
+func sendClip(bot *tgbotapi.BotAPI, ChatID int64, ReplyTo int, Clip frigate.EventClip, URL string, Name string, Caption string) error {
+	lang := i18n.Language(ChatID)
+	var files []tgbotapi.RequestFileData
+	note := ""
+	if Clip.File != nil {
+		files = append(files, Clip.File)
+	} else {
+		oversize := frigate.FitClipFile(Clip.Path, URL, Name)
+		defer oversize.Remove()
+		for _, file := range oversize.Files {
+			files = append(files, frigate.MediaFile(file))
+		}
+		note = oversize.Note
+		if note == "" {
+			note = i18n.T(lang, "recording_too_large")
+		}
+	}
+
+	if len(files) == 0 {
+		return sendClipNote(bot, ChatID, ReplyTo, Caption, note)
+	}
+	for i, file := range files {
+		caption := Caption
+		if len(files) > 1 {
+			caption += " " + i18n.T(lang, "part", i+1, len(files))
+		}
+		if i == 0 && note != "" {
+			caption = strings.TrimPrefix(caption+"\n"+note, "\n")
+		}
+		video := tgbotapi.NewVideo(ChatID, file)
+		video.Caption = caption
+		video.ParseMode = markup.ParseMode
+		video.SupportsStreaming = true
+		video.ReplyToMessageID = ReplyTo
+		if _, err := markup.Send(bot, video); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendClipNote sends note instead of clip, e.g. link to clip
+func sendClipNote(bot *tgbotapi.BotAPI, ChatID int64, ReplyTo int, Caption string, Note string) error {
+	text := tgbotapi.NewMessage(ChatID, strings.TrimPrefix(Caption+"\n"+Note, "\n"))
+	text.ParseMode = markup.ParseMode
+	text.ReplyToMessageID = ReplyTo
+	_, err := markup.Send(bot, text)
+	return err
+}
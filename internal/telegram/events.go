@@ -0,0 +1,302 @@
+package telegram
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/frigate"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+const (
+	callbackEventsPage = "evp"
+	callbackEventOpen  = "evo"
+	callbackEventClip  = "evc"
+)
+
+// Default and max count of events on page of events browser
+var (
+	EventsPageSize    = 5
+	EventsMaxPageSize = 10
+)
+
+// EventsQuery is state of events browser, it is kept in callback data
+type EventsQuery struct {
+	Camera string
+	Label  string
+	Limit  int
+	Page   int
+	// Events are listed before this time, so pages don't shift with new events
+	Before int64
+}
+
+// ParseEventsQuery parses `[camera] [label] [N]` arguments, `all` matches any
+func ParseEventsQuery(Args string) (EventsQuery, error) {
+	query := EventsQuery{Camera: "all", Label: "all", Limit: EventsPageSize, Before: time.Now().Unix()}
+	var names []string
+	for _, arg := range strings.Fields(Args) {
+		if limit, err := strconv.Atoi(arg); err == nil {
+			if limit <= 0 || limit > EventsMaxPageSize {
+				return query, i18n.NewError("events_count", EventsMaxPageSize)
+			}
+			query.Limit = limit
+			continue
+		}
+		// Separator of callback data
+		if strings.Contains(arg, "|") {
+			return query, i18n.NewError("wrong_name", arg)
+		}
+		names = append(names, arg)
+	}
+	if len(names) > 2 {
+		return query, i18n.NewError("events_usage")
+	}
+	if len(names) > 0 {
+		query.Camera = names[0]
+	}
+	if len(names) > 1 {
+		query.Label = names[1]
+	}
+	return query, nil
+}
+
+func (q EventsQuery) data() string {
+	return callbackData(callbackEventsPage, q.Camera, q.Label,
+		strconv.Itoa(q.Limit), strconv.Itoa(q.Page), strconv.FormatInt(q.Before, 10))
+}
+
+func parseEventsData(Data []string) (EventsQuery, error) {
+	var query EventsQuery
+	if len(Data) != 6 {
+		return query, i18n.NewError("wrong_callback")
+	}
+	query.Camera = Data[1]
+	query.Label = Data[2]
+	var err error
+	if query.Limit, err = strconv.Atoi(Data[3]); err != nil {
+		return query, err
+	}
+	if query.Page, err = strconv.Atoi(Data[4]); err != nil {
+		return query, err
+	}
+	query.Before, err = strconv.ParseInt(Data[5], 10, 64)
+	return query, err
+}
+
+// browserInstance returns instance and camera of query, `all` cameras of
+// first instance are used without camera, `instance/all` selects instance.
+func browserInstance(Camera string) (config.FrigateInstance, string, error) {
+	if Camera == "all" {
+		return config.New().FrigateInstances[0], "all", nil
+	}
+	return frigate.FindCamera(Camera)
+}
+
+// EventsPage returns text and keyboard of events browser page in Lang
+func EventsPage(Query EventsQuery, Lang string) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	instance, camera, err := browserInstance(Query.Camera)
+	if err != nil {
+		return "", keyboard, err
+	}
+	// Frigate has no offset, so previous pages are requested and skipped.
+	// One more event is requested to know whether next page exists.
+	params := url.Values{}
+	params.Set("camera", camera)
+	params.Set("label", Query.Label)
+	params.Set("before", strconv.FormatInt(Query.Before, 10))
+	params.Set("limit", strconv.Itoa(Query.Limit*(Query.Page+1)+1))
+	params.Set("include_thumbnails", "0")
+	events, err := frigate.QueryEvents(instance, params)
+	if err != nil {
+		return "", keyboard, err
+	}
+	start := Query.Limit * Query.Page
+	end := start + Query.Limit
+	hasNext := len(events) > end
+	if end > len(events) {
+		end = len(events)
+	}
+	if start > len(events) {
+		start = len(events)
+	}
+	events = events[start:end]
+
+	text := "<b>" + i18n.T(Lang, "events") + "</b> " + markup.Code(Query.Camera) + " " + markup.Code(Query.Label) +
+		", " + i18n.T(Lang, "page", Query.Page+1) + "\n"
+	if len(events) == 0 {
+		text += "┗ " + i18n.T(Lang, "no_events_found") + "\n"
+	}
+	var openRow []tgbotapi.InlineKeyboardButton
+	for i, event := range events {
+		prefix := "┣"
+		if i == len(events)-1 {
+			prefix = "┗"
+		}
+		duration := i18n.T(Lang, "in_progress")
+		if event.EndTime != 0 {
+			duration = i18n.FormatDuration(Lang, time.Duration(event.EndTime-event.StartTime)*time.Second)
+		}
+		text += fmt.Sprintf("%s%d. %s %s %s <code>%.0f%%</code> %s\n", prefix, i+1,
+			markup.Code(i18n.LocalTime(time.Unix(int64(event.StartTime), 0)).Format("01-02 15:04:05")),
+			markup.Code(frigate.CameraName(instance, event.Camera)), markup.Code(event.Label), event.Data.TopScore*100, duration)
+		openRow = append(openRow, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(i+1),
+			callbackData(callbackEventOpen, instance.Name, event.ID)))
+	}
+
+	var pageRow []tgbotapi.InlineKeyboardButton
+	if Query.Page > 0 {
+		prev := Query
+		prev.Page--
+		pageRow = append(pageRow, tgbotapi.NewInlineKeyboardButtonData("◀️ "+i18n.T(Lang, "prev"), prev.data()))
+	}
+	if hasNext {
+		next := Query
+		next.Page++
+		pageRow = append(pageRow, tgbotapi.NewInlineKeyboardButtonData(i18n.T(Lang, "next")+" ▶️", next.data()))
+	}
+	for _, row := range [][]tgbotapi.InlineKeyboardButton{openRow, pageRow} {
+		if len(row) != 0 {
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+		}
+	}
+	return text, keyboard, nil
+}
+
+// Events sends first page of events browser
+func Events(msg tgbotapi.MessageConfig, conf *config.Config, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID != conf.TelegramChatID {
+		return false, msg
+	}
+	lang := i18n.Language(msg.BaseChat.ChatID)
+	query, err := ParseEventsQuery(args)
+	if err != nil {
+		msg.Text = i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	text, keyboard, err := EventsPage(query, lang)
+	if err != nil {
+		msg.Text = i18n.T(lang, "error_events") + ": " + i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	msg.Text = text
+	msg.ParseMode = markup.ParseMode
+	if len(keyboard.InlineKeyboard) != 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	return true, msg
+}
+
+// EventsPageCallback replaces events browser message with requested page
+func EventsPageCallback(query *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI, data []string) string {
+	lang := i18n.Language(query.Message.Chat.ID)
+	eventsQuery, err := parseEventsData(data)
+	if err != nil {
+		return i18n.ErrorText(lang, err)
+	}
+	text, keyboard, err := EventsPage(eventsQuery, lang)
+	if err != nil {
+		return i18n.T(lang, "error_events") + ": " + i18n.ErrorText(lang, err)
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	edit.ParseMode = markup.ParseMode
+	if _, err := markup.Request(bot, edit); err != nil {
+		log.Error.Println("Error editing events page: " + err.Error())
+		return i18n.T(lang, "error_events_page")
+	}
+	return ""
+}
+
+func eventCaption(FrigateEvent frigate.EventStruct, Lang string) string {
+	instance := frigate.GetInstance(FrigateEvent.Instance)
+	text := "#" + frigate.NormalizeTagText(FrigateEvent.Camera) + " #" + frigate.NormalizeTagText(FrigateEvent.Label) + "\n"
+	text += "┣<b>" + i18n.T(Lang, "start_time") + "</b>\n┗ " + markup.Code(i18n.FormatTime(time.Unix(int64(FrigateEvent.StartTime), 0))) + "\n"
+	duration := i18n.T(Lang, "in_progress")
+	if FrigateEvent.EndTime != 0 {
+		duration = i18n.FormatDuration(Lang, time.Duration(FrigateEvent.EndTime-FrigateEvent.StartTime)*time.Second)
+	}
+	text += "┣<b>" + i18n.T(Lang, "duration") + "</b>\n┗ " + markup.Code(duration) + "\n"
+	text += fmt.Sprintf("┣<b>%s</b>\n┗ <code>%.1f%%</code>\n", i18n.T(Lang, "top_score"), FrigateEvent.Data.TopScore*100)
+	if zones := frigate.GetTagList(FrigateEvent.Zones); len(zones) != 0 {
+		text += "┣<b>" + i18n.T(Lang, "zones") + "</b>\n┗ " + markup.CodeList(zones) + "\n"
+	}
+	text += "┗" + markup.Link(i18n.T(Lang, "event"), instance.ExternalURL+"/events?cameras="+url.QueryEscape(FrigateEvent.Camera)+"&labels="+url.QueryEscape(FrigateEvent.Label))
+	return text
+}
+
+// OpenEvent sends snapshot of event with button requesting clip
+func OpenEvent(query *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI, data []string) string {
+	lang := i18n.Language(query.Message.Chat.ID)
+	if len(data) != 3 {
+		return i18n.T(lang, "wrong_callback")
+	}
+	event, err := frigate.GetEvent(frigate.GetInstance(data[1]), data[2])
+	if err != nil {
+		return i18n.T(lang, "error_event") + ": " + i18n.ErrorText(lang, err)
+	}
+	params, _ := frigate.SnapshotParams(event.Instance, event.Camera)
+	image, err := frigate.GetEventSnapshot(event, params)
+	if err != nil {
+		log.Warn.Println("Error getting snapshot of event " + event.ID + ": " + err.Error())
+		msg := tgbotapi.NewMessage(query.Message.Chat.ID, eventCaption(event, lang))
+		msg.ParseMode = markup.ParseMode
+		msg.DisableWebPagePreview = true
+		if _, err := markup.Send(bot, msg); err != nil {
+			log.Error.Println("Error sending event: " + err.Error())
+		}
+		return ""
+	}
+	photo := tgbotapi.NewPhoto(query.Message.Chat.ID, tgbotapi.FileBytes{Name: event.ID + ".jpg", Bytes: image})
+	photo.Caption = eventCaption(event, lang)
+	photo.ParseMode = markup.ParseMode
+	if event.HasClip {
+		photo.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎬 "+i18n.T(lang, "clip"), callbackData(callbackEventClip, data[1], data[2])),
+		))
+	}
+	if _, err := markup.Send(bot, photo); err != nil {
+		log.Error.Println("Error sending event: " + err.Error())
+		return i18n.T(lang, "error_send_event")
+	}
+	return ""
+}
+
+// SendEventClip sends clip of event, link if clip is too large for Telegram
+func SendEventClip(query *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI, data []string) string {
+	lang := i18n.Language(query.Message.Chat.ID)
+	if len(data) != 3 {
+		return i18n.T(lang, "wrong_callback")
+	}
+	event, err := frigate.GetEvent(frigate.GetInstance(data[1]), data[2])
+	if err != nil {
+		return i18n.T(lang, "error_event") + ": " + i18n.ErrorText(lang, err)
+	}
+	if event.EndTime == 0 {
+		return i18n.T(lang, "clip_not_ready")
+	}
+	// Clip of camera with privacy regions can't be blurred
+	if note := frigate.PrivateClipNote(event.Instance, event.Camera, frigate.ClipURL(event)); note != "" {
+		if err := sendClipNote(bot, query.Message.Chat.ID, query.Message.MessageID, "", note); err != nil {
+			log.Error.Println("Error sending clip: " + err.Error())
+			return i18n.T(lang, "error_send_clip")
+		}
+		return ""
+	}
+	clip, err := frigate.OpenEventClip(event)
+	if err != nil {
+		return i18n.T(lang, "error_clip") + ": " + i18n.ErrorText(lang, err)
+	}
+	defer clip.Close()
+	if err := sendClip(bot, query.Message.Chat.ID, query.Message.MessageID, clip, frigate.ClipURL(event), "event "+event.ID, ""); err != nil {
+		log.Error.Println("Error sending clip: " + err.Error())
+		return i18n.T(lang, "error_send_clip")
+	}
+	return ""
+}
@@ -0,0 +1,13 @@
+package telegram
+
+import (
+	"os"
+	"testing"
+
+	"github.com/oldtyt/frigate-telegram/internal/log"
+)
+
+func TestMain(m *testing.M) {
+	log.LogFunc()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,198 @@
+package telegram
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/frigate"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
+	"github.com/oldtyt/frigate-telegram/internal/imaging"
+	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
+)
+
+const callbackSnapshot = "snap"
+
+// ParseSnapshotParams parses `bbox`, `height=N` and `quality=N` arguments
+func ParseSnapshotParams(Args []string) (url.Values, error) {
+	params := url.Values{}
+	for _, arg := range Args {
+		key, value, _ := strings.Cut(arg, "=")
+		switch key {
+		case "bbox":
+			params.Set("bbox", "1")
+		case "height", "quality":
+			if _, err := strconv.Atoi(value); err != nil {
+				return params, i18n.NewError("wrong_value", key, value)
+			}
+			params.Set(key, value)
+		default:
+			return params, i18n.NewError("unknown_argument", arg)
+		}
+	}
+	return params, nil
+}
+
+func snapshotCaption(Name string) string {
+	return "#" + frigate.NormalizeTagText(Name) + " " + markup.Code(i18n.FormatTime(time.Now()))
+}
+
+func snapshotKeyboard(Name string, Params url.Values, Lang string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 "+i18n.T(Lang, "refresh"), callbackData(callbackSnapshot, Name, Params.Encode())),
+	))
+}
+
+func Snapshot(msg tgbotapi.MessageConfig, conf *config.Config, bot *tgbotapi.BotAPI, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID != conf.TelegramChatID {
+		return false, msg
+	}
+	lang := i18n.Language(msg.BaseChat.ChatID)
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		msg.Text = i18n.T(lang, "snapshot_usage")
+		return true, msg
+	}
+	params, err := ParseSnapshotParams(fields[1:])
+	if err != nil {
+		msg.Text = i18n.ErrorText(lang, err)
+		return true, msg
+	}
+
+	if fields[0] == "all" {
+		SnapshotAll(msg, conf, bot, params)
+		return true, msg
+	}
+
+	instance, camera, err := frigate.FindCamera(fields[0])
+	if err != nil {
+		msg.Text = i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	data, err := frigate.GetLatestSnapshot(instance, camera, params)
+	if err != nil {
+		msg.Text = i18n.T(lang, "error_snapshot") + ": " + i18n.ErrorText(lang, err)
+		return true, msg
+	}
+	name := frigate.CameraName(instance, camera)
+	photo := tgbotapi.NewPhoto(msg.BaseChat.ChatID, tgbotapi.FileBytes{Name: camera + ".jpg", Bytes: data})
+	photo.Caption = snapshotCaption(name)
+	photo.ParseMode = markup.ParseMode
+	photo.ReplyMarkup = snapshotKeyboard(name, params, lang)
+	if _, err := markup.Send(bot, photo); err != nil {
+		log.Error.Println("Error sending snapshot: " + err.Error())
+		msg.Text = i18n.T(lang, "error_send_snapshot") + ": " + i18n.ErrorText(lang, err)
+	}
+	return true, msg
+}
+
+// SnapshotTiles returns latest snapshots of all cameras as collage tiles
+func SnapshotTiles(conf *config.Config, params url.Values) []imaging.Tile {
+	var tiles []imaging.Tile
+	for _, instance := range conf.FrigateInstances {
+		cameras, err := frigate.GetCameras(instance)
+		if err != nil {
+			log.Error.Println("Error getting cameras: " + err.Error())
+			continue
+		}
+		for _, camera := range cameras {
+			tile, err := frigate.SnapshotTile(instance, camera, params)
+			if err != nil {
+				log.Warn.Println("Error getting snapshot of " + camera + ": " + err.Error())
+				continue
+			}
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
+}
+
+// SnapshotAll sends latest snapshots of all cameras as labeled collage
+func SnapshotAll(msg tgbotapi.MessageConfig, conf *config.Config, bot *tgbotapi.BotAPI, params url.Values) {
+	lang := i18n.Language(msg.BaseChat.ChatID)
+	tiles := SnapshotTiles(conf, params)
+	if len(tiles) == 0 {
+		reply := tgbotapi.NewMessage(msg.BaseChat.ChatID, i18n.T(lang, "no_snapshots"))
+		if _, err := bot.Send(reply); err != nil {
+			log.Error.Println(err.Error())
+		}
+		return
+	}
+	for i := 0; i < len(tiles); i += frigate.CollageLimit {
+		end := i + frigate.CollageLimit
+		if end > len(tiles) {
+			end = len(tiles)
+		}
+		data, err := frigate.EncodeCollage(tiles[i:end])
+		if err != nil {
+			log.Error.Println("Error encoding snapshots collage: " + err.Error())
+			continue
+		}
+		photo := tgbotapi.NewPhoto(msg.BaseChat.ChatID, tgbotapi.FileBytes{Name: "snapshots.jpg", Bytes: data})
+		photo.Caption = snapshotCaption("all")
+		photo.ParseMode = markup.ParseMode
+		// Refreshed collage must have the same cameras
+		if len(tiles) <= frigate.CollageLimit {
+			photo.ReplyMarkup = snapshotKeyboard("all", params, lang)
+		}
+		if _, err := markup.Send(bot, photo); err != nil {
+			log.Error.Println("Error sending snapshots: " + err.Error())
+		}
+	}
+}
+
+// RefreshSnapshot replaces photo of message with new snapshot
+func RefreshSnapshot(query *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI, data []string) string {
+	lang := i18n.Language(query.Message.Chat.ID)
+	if len(data) != 3 {
+		return i18n.T(lang, "wrong_callback")
+	}
+	params, err := url.ParseQuery(data[2])
+	if err != nil {
+		return i18n.T(lang, "wrong_callback")
+	}
+	var image []byte
+	if data[1] == "all" {
+		tiles := SnapshotTiles(config.New(), params)
+		if len(tiles) == 0 {
+			return i18n.T(lang, "no_snapshots")
+		}
+		if len(tiles) > frigate.CollageLimit {
+			tiles = tiles[:frigate.CollageLimit]
+		}
+		image, err = frigate.EncodeCollage(tiles)
+		if err != nil {
+			return i18n.T(lang, "error_collage") + ": " + i18n.ErrorText(lang, err)
+		}
+	} else {
+		instance, camera, err := frigate.FindCamera(data[1])
+		if err != nil {
+			return i18n.ErrorText(lang, err)
+		}
+		image, err = frigate.GetLatestSnapshot(instance, camera, params)
+		if err != nil {
+			return i18n.T(lang, "error_snapshot") + ": " + i18n.ErrorText(lang, err)
+		}
+	}
+	photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: "snapshot.jpg", Bytes: image})
+	photo.Caption = snapshotCaption(data[1])
+	photo.ParseMode = markup.ParseMode
+	keyboard := snapshotKeyboard(data[1], params, lang)
+	edit := tgbotapi.EditMessageMediaConfig{
+		BaseEdit: tgbotapi.BaseEdit{
+			ChatID:      query.Message.Chat.ID,
+			MessageID:   query.Message.MessageID,
+			ReplyMarkup: &keyboard,
+		},
+		Media: photo,
+	}
+	if _, err := markup.Request(bot, edit); err != nil {
+		log.Error.Println("Error refreshing snapshot: " + err.Error())
+		return i18n.T(lang, "error_refresh")
+	}
+	return i18n.T(lang, "refreshed")
+}
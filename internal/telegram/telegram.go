@@ -2,14 +2,22 @@ package telegram
 
 import (
 	"strconv"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/oldtyt/frigate-telegram/internal/config"
+	"github.com/oldtyt/frigate-telegram/internal/history"
+	"github.com/oldtyt/frigate-telegram/internal/i18n"
 	"github.com/oldtyt/frigate-telegram/internal/log"
+	"github.com/oldtyt/frigate-telegram/internal/markup"
 	"github.com/oldtyt/frigate-telegram/internal/redis"
+	"github.com/oldtyt/frigate-telegram/internal/report"
 )
 
-var redisErrorText string = "Error setting value, check logs."
+// Max count of updates handled at once, so long commands like /clip don't
+// block other commands and buttons
+var UpdateWorkers = 8
 
 // ChatBot is needed to check the work of the bot.
 func ChatBot(bot *tgbotapi.BotAPI, conf *config.Config) {
@@ -18,64 +26,182 @@ func ChatBot(bot *tgbotapi.BotAPI, conf *config.Config) {
 
 	updates := bot.GetUpdatesChan(u)
 
+	workers := make(chan struct{}, UpdateWorkers)
 	for update := range updates {
-		if update.Message == nil { // ignore any non-Message updates
-			continue
-		}
+		workers <- struct{}{}
+		go func(update tgbotapi.Update) {
+			defer func() { <-workers }()
+			HandleUpdate(update, conf, bot)
+		}(update)
+	}
+}
 
-		if !update.Message.IsCommand() { // ignore any non-command Messages
-			continue
-		}
+// HandleUpdate handles command or inline button
+func HandleUpdate(update tgbotapi.Update, conf *config.Config, bot *tgbotapi.BotAPI) {
+	if update.CallbackQuery != nil {
+		Callback(update.CallbackQuery, conf, bot)
+		return
+	}
+
+	if update.Message == nil { // ignore any non-Message updates
+		return
+	}
+
+	if !update.Message.IsCommand() { // ignore any non-command Messages
+		return
+	}
+
+	// Create a new MessageConfig. We don't have text yet,
+	// so we leave it empty.
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
+	sendMessage := true
+
+	// Extract the command from the Message.
+	switch update.Message.Command() {
+	case "help":
+		sendMessage, msg = Help(msg, conf)
+	case "ping":
+		msg.Text = "pong"
+	case "king":
+		msg.Text = "kong"
+	case "pong":
+		msg.Text = "ping"
+	case "status":
+		sendMessage, msg = Status(msg, conf)
+	case "stop":
+		sendMessage, msg = Stop(msg, conf)
+	case "resume":
+		sendMessage, msg = Resume(msg, conf)
+	case "mute":
+		sendMessage, msg = Mute(msg, conf)
+	case "unmute":
+		sendMessage, msg = Unmute(msg, conf)
+	case "cameras":
+		sendMessage, msg = Cameras(msg, conf)
+	case "camera":
+		sendMessage, msg = Camera(msg, conf, bot, update.Message.CommandArguments())
+	case "snapshot":
+		sendMessage, msg = Snapshot(msg, conf, bot, update.Message.CommandArguments())
+	case "events":
+		sendMessage, msg = Events(msg, conf, update.Message.CommandArguments())
+	case "clip":
+		sendMessage, msg = Clip(msg, conf, bot, update.Message.CommandArguments())
+	case "history":
+		sendMessage, msg = History(msg, conf, update.Message.CommandArguments())
+	case "report":
+		sendMessage, msg = Report(msg, conf, bot, update.Message.CommandArguments())
+	case "language":
+		sendMessage, msg = Language(msg, conf, update.Message.CommandArguments())
+	default:
+		msg.Text = i18n.T(i18n.Language(update.Message.Chat.ID), "unknown_command")
+	}
+
+	if !sendMessage {
+		msg = tgbotapi.NewMessage(update.Message.Chat.ID, "")
+		msg.Text = i18n.T(i18n.Language(update.Message.Chat.ID), "unknown_command")
+	}
+	// Handler already sent reply
+	if msg.Text == "" {
+		return
+	}
+	// Long reply, e.g. history with large limit, is split into several messages
+	if _, err := markup.SendLong(bot, msg); err != nil {
+		log.Error.Println("Error sending message: " + err.Error())
+	}
+}
 
-		// Create a new MessageConfig. We don't have text yet,
-		// so we leave it empty.
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
-		sendMessage := true
-
-		// Extract the command from the Message.
-		switch update.Message.Command() {
-		case "help":
-			sendMessage, msg = Help(msg, conf)
-		case "ping":
-			msg.Text = "pong"
-		case "king":
-			msg.Text = "kong"
-		case "pong":
-			msg.Text = "ping"
-		case "status":
-			sendMessage, msg = Status(msg, conf)
-		case "stop":
-			sendMessage, msg = Stop(msg, conf)
-		case "resume":
-			sendMessage, msg = Resume(msg, conf)
-		case "mute":
-			sendMessage, msg = Mute(msg, conf)
-		case "unmute":
-			sendMessage, msg = Unmute(msg, conf)
+// Callback handles inline keyboard buttons, data is `action|arg|...`
+func Callback(query *tgbotapi.CallbackQuery, conf *config.Config, bot *tgbotapi.BotAPI) {
+	answer := ""
+	data, err := parseCallbackData(query.Data)
+	if query.Message == nil || query.Message.Chat.ID != conf.TelegramChatID {
+		answer = i18n.T(i18n.Language(conf.TelegramChatID), "unknown_command")
+	} else if err != nil {
+		answer = i18n.ErrorText(i18n.Language(query.Message.Chat.ID), err)
+	} else {
+		switch data[0] {
+		case callbackSnapshot:
+			answer = RefreshSnapshot(query, bot, data)
+		case callbackEventsPage:
+			answer = EventsPageCallback(query, bot, data)
+		case callbackEventOpen:
+			answer = OpenEvent(query, bot, data)
+		case callbackEventClip:
+			answer = SendEventClip(query, bot, data)
 		default:
-			msg.Text = "I don't know that command"
+			answer = i18n.T(i18n.Language(conf.TelegramChatID), "unknown_command")
 		}
+	}
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, answer)); err != nil {
+		log.Error.Println("Error answering callback: " + err.Error())
+	}
+}
 
-		if !sendMessage {
-			msg = tgbotapi.NewMessage(update.Message.Chat.ID, "")
-			msg.Text = "I don't know that command"
-		}
-		if _, err := bot.Send(msg); err != nil {
-			log.Error.Fatalln("Error sending message: " + err.Error())
-		}
+// Telegram limits callback data of button to 64 bytes
+const maxCallbackData = 64
+
+// Action of button with data stored in Redis
+const callbackRef = "ref"
+
+// Storage of long callback data, replaced in tests
+var (
+	storeCallbackData = func(Data string) string {
+		return redis.SetCallbackData(Data, time.Duration(config.New().RedisTTL)*time.Second)
+	}
+	loadCallbackData = redis.GetCallbackData
+)
+
+// callbackData returns data of button `action|arg|...`. Data longer than
+// Telegram limit, e.g. with long camera or instance name, is stored in Redis
+// and button keeps short reference to it.
+func callbackData(Action string, Args ...string) string {
+	data := strings.Join(append([]string{Action}, Args...), "|")
+	if len(data) <= maxCallbackData {
+		return data
 	}
+	return callbackRef + "|" + storeCallbackData(data)
+}
+
+// parseCallbackData splits data of button, reference is resolved from Redis
+func parseCallbackData(Data string) ([]string, error) {
+	data := strings.Split(Data, "|")
+	if data[0] != callbackRef {
+		return data, nil
+	}
+	if len(data) != 2 {
+		return nil, i18n.NewError("wrong_callback")
+	}
+	stored, ok := loadCallbackData(data[1])
+	if !ok {
+		return nil, i18n.NewError("button_expired")
+	}
+	return strings.Split(stored, "|"), nil
 }
 
 func Help(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.MessageConfig) {
 	if msg.BaseChat.ChatID == conf.TelegramChatID {
-		text := "Stop send events: /stop\n"
-		text += "Resume send events: /resume\n"
-		text += "Mute send events: /mute\n"
-		text += "Unmute send events: /unmute\n"
-		text += "Current status: /status\n"
-		text += "Comand working only in chat id: `" + strconv.FormatInt(conf.TelegramChatID, 10) + "` (Current chat)"
+		lang := i18n.Language(msg.BaseChat.ChatID)
+		text := ""
+		for _, command := range []struct{ key, usage string }{
+			{"help_stop", "/stop"},
+			{"help_resume", "/resume"},
+			{"help_mute", "/mute"},
+			{"help_unmute", "/unmute"},
+			{"help_status", "/status"},
+			{"help_cameras", "/cameras"},
+			{"help_camera", "/camera <name>"},
+			{"help_snapshot", "/snapshot <camera|all> [bbox] [height=N] [quality=N]"},
+			{"help_clip", "/clip <camera> <from> <duration>"},
+			{"help_events", "/events [camera] [label] [N]"},
+			{"help_report", "/report today|yesterday|week"},
+			{"help_history", "/history camera=porch label=person status=sent since=2h limit=20"},
+			{"help_language", "/language " + strings.Join(i18n.Languages(), "|")},
+		} {
+			text += markup.Escape(i18n.T(lang, command.key)+": "+command.usage) + "\n"
+		}
+		text += i18n.T(lang, "help_chat", markup.Code(strconv.FormatInt(conf.TelegramChatID, 10)))
 		msg.Text = text
-		msg.ParseMode = tgbotapi.ModeMarkdown
+		msg.ParseMode = markup.ParseMode
 		return true, msg
 	}
 	return false, msg
@@ -83,10 +209,12 @@ func Help(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.Messa
 
 func Status(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.MessageConfig) {
 	if msg.BaseChat.ChatID == conf.TelegramChatID {
-		text := "Send event: `" + strconv.FormatBool(redis.GetStateSendEvent()) + "`\n"
-		text += "Mute event: `" + strconv.FormatBool(redis.GetStateMuteEvent()) + "`\n"
+		lang := i18n.Language(msg.BaseChat.ChatID)
+		text := i18n.T(lang, "status_send") + ": " + markup.Code(strconv.FormatBool(redis.GetStateSendEvent())) + "\n"
+		text += i18n.T(lang, "status_mute") + ": " + markup.Code(strconv.FormatBool(redis.GetStateMuteEvent())) + "\n"
+		text += i18n.T(lang, "status_language") + ": " + markup.Code(lang) + "\n"
 		msg.Text = text
-		msg.ParseMode = tgbotapi.ModeMarkdown
+		msg.ParseMode = markup.ParseMode
 		return true, msg
 	}
 	return false, msg
@@ -96,10 +224,10 @@ func Stop(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.Messa
 	if msg.BaseChat.ChatID == conf.TelegramChatID {
 		r := redis.SetStateSendEvent(true)
 		if r {
-			msg.Text = "Stop send message."
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "stopped")
 			return true, msg
 		} else {
-			msg.Text = redisErrorText
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "redis_error")
 			return true, msg
 		}
 	}
@@ -110,10 +238,10 @@ func Resume(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.Mes
 	if msg.BaseChat.ChatID == conf.TelegramChatID {
 		r := redis.SetStateSendEvent(false)
 		if r {
-			msg.Text = "Resume send message."
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "resumed")
 			return true, msg
 		} else {
-			msg.Text = redisErrorText
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "redis_error")
 			return true, msg
 		}
 	}
@@ -124,10 +252,10 @@ func Mute(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.Messa
 	if msg.BaseChat.ChatID == conf.TelegramChatID {
 		r := redis.SetStateMuteEvent(true)
 		if r {
-			msg.Text = "Mute send message."
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "muted")
 			return true, msg
 		} else {
-			msg.Text = redisErrorText
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "redis_error")
 			return true, msg
 		}
 	}
@@ -138,12 +266,67 @@ func Unmute(msg tgbotapi.MessageConfig, conf *config.Config) (bool, tgbotapi.Mes
 	if msg.BaseChat.ChatID == conf.TelegramChatID {
 		r := redis.SetStateMuteEvent(false)
 		if r {
-			msg.Text = "Unmute send message."
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "unmuted")
 			return true, msg
 		} else {
-			msg.Text = redisErrorText
+			msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "redis_error")
 			return true, msg
 		}
 	}
 	return false, msg
 }
+
+// Language shows or sets language of chat
+func Language(msg tgbotapi.MessageConfig, conf *config.Config, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID != conf.TelegramChatID {
+		return false, msg
+	}
+	lang := strings.ToLower(strings.TrimSpace(args))
+	if lang == "" {
+		msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "language_current",
+			i18n.Language(msg.BaseChat.ChatID), strings.Join(i18n.Languages(), ", "))
+		return true, msg
+	}
+	if !i18n.Supported(lang) {
+		msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "language_unknown", strings.Join(i18n.Languages(), ", "))
+		return true, msg
+	}
+	if !redis.SetChatLanguage(msg.BaseChat.ChatID, lang) {
+		msg.Text = i18n.T(i18n.Language(msg.BaseChat.ChatID), "redis_error")
+		return true, msg
+	}
+	msg.Text = i18n.T(lang, "language_set")
+	return true, msg
+}
+
+func Report(msg tgbotapi.MessageConfig, conf *config.Config, bot *tgbotapi.BotAPI, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID == conf.TelegramChatID {
+		lang := i18n.Language(msg.BaseChat.ChatID)
+		period := strings.TrimSpace(args)
+		if period == "" {
+			period = report.PeriodToday
+		}
+		if err := report.Send(bot, msg.BaseChat.ChatID, period); err != nil {
+			log.Error.Println("Error sending report: " + err.Error())
+			msg.Text = i18n.T(lang, "error_report") + ": " + i18n.ErrorText(lang, err)
+			return true, msg
+		}
+		return true, msg
+	}
+	return false, msg
+}
+
+func History(msg tgbotapi.MessageConfig, conf *config.Config, args string) (bool, tgbotapi.MessageConfig) {
+	if msg.BaseChat.ChatID == conf.TelegramChatID {
+		lang := i18n.Language(msg.BaseChat.ChatID)
+		filter, err := history.ParseFilter(args, 20)
+		if err != nil {
+			msg.Text = i18n.T(lang, "error_filter") + ": " + i18n.ErrorText(lang, err)
+			return true, msg
+		}
+		msg.Text = history.Format(history.Query(filter), lang)
+		msg.ParseMode = markup.ParseMode
+		return true, msg
+	}
+	return false, msg
+}
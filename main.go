@@ -1,14 +1,11 @@
 package main
 
 import (
-	"strconv"
-	"time"
-
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/oldtyt/frigate-telegram/internal/config"
 	"github.com/oldtyt/frigate-telegram/internal/frigate"
 	"github.com/oldtyt/frigate-telegram/internal/log"
-	"github.com/oldtyt/frigate-telegram/internal/redis"
+	"github.com/oldtyt/frigate-telegram/internal/report"
 	"github.com/oldtyt/frigate-telegram/internal/restapi"
 	"github.com/oldtyt/frigate-telegram/internal/telegram"
 )
@@ -27,7 +24,13 @@ func main() {
 
 	// Prepare startup msg
 	startupMsg := "Starting frigate-telegram. "
-	startupMsg += "Frigate URL: " + conf.FrigateURL
+	for _, instance := range conf.FrigateInstances {
+		if instance.Name != "" {
+			startupMsg += "\nFrigate " + instance.Name + " URL: " + instance.URL
+		} else {
+			startupMsg += "Frigate URL: " + instance.URL
+		}
+	}
 	log.Info.Println(startupMsg)
 
 	if conf.RestAPIEnable {
@@ -35,7 +38,7 @@ func main() {
 	}
 
 	// Initializing telegram bot
-	bot, err := tgbotapi.NewBotAPI(conf.TelegramBotToken)
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(conf.TelegramBotToken, conf.TelegramAPIEndpoint)
 	if err != nil {
 		log.Error.Fatalln("Error initalizing telegram bot: " + err.Error())
 	}
@@ -51,20 +54,22 @@ func main() {
 	// Starting ping command handler(healthcheck)
 	go telegram.ChatBot(bot, conf)
 
-	FrigateEventsURL := conf.FrigateURL + "/api/events"
+	// Remove media files left if previous run died while sending
+	frigate.CleanMediaCache()
+	frigate.CheckFFmpeg()
 
-	if conf.SendTextEvent {
-		go frigate.NotifyEvents(bot, FrigateEventsURL)
-	}
-	// Starting loop for getting events from Frigate
-	for {
-		if redis.GetStateSendEvent() {
-			FrigateEvents := frigate.GetEvents(FrigateEventsURL, bot, true)
-			frigate.ParseEvents(FrigateEvents, bot, false)
-		} else {
-			log.Debug.Println("Skiping send events.")
+	// Send digest of events missed while bot was down
+	frigate.SendDowntimeDigest(bot)
+
+	go report.RunScheduler(bot)
+
+	// Starting loops for getting events from every Frigate instance
+	for _, instance := range conf.FrigateInstances {
+		if conf.SendTextEvent {
+			go frigate.NotifyEvents(bot, instance)
 		}
-		time.Sleep(time.Duration(conf.SleepTime) * time.Second)
-		log.Debug.Println("Sleeping for " + strconv.Itoa(conf.SleepTime) + " seconds.")
+		go frigate.PollEvents(bot, instance)
+		go frigate.MonitorHealth(bot, instance)
 	}
+	select {}
 }